@@ -0,0 +1,322 @@
+package netio
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeLayouts are the layouts accepted for time.Time fields, in the order
+// they are tried. Besides RFC 3339 they cover the values produced by HTML
+// date and datetime-local inputs.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+var (
+	timeType            = reflect.TypeFor[time.Time]()
+	durationType        = reflect.TypeFor[time.Duration]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// binder decodes string values, such as form fields, into struct fields
+// named by a struct tag.
+type binder struct {
+	// tag names the struct tag holding field names; fields without it
+	// fall back to their json name
+	tag string
+	// defaults applies the `default` tag of fields without a value
+	defaults bool
+	// split splits the values of slice fields on commas
+	split bool
+	// skipEmpty treats empty values as missing
+	skipEmpty bool
+}
+
+// bind sets the fields of the struct dst points to from values. Values that
+// cannot be converted are recorded on v, keyed by field name like
+// Validator.Struct keys. Fields without a value, and fields of types that
+// cannot be converted from a string, are left untouched.
+//
+// bind panics if dst is not a non-nil pointer to a struct, or if a default
+// value cannot be converted to its field's type.
+func (b binder) bind(values map[string][]string, dst any, v *Validator) {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		panic("netio: bind requires a non-nil pointer to a struct")
+	}
+	b.bindStruct(values, "", rv.Elem(), v)
+}
+
+// bindStruct sets each field of rv from the value named prefix + field name.
+func (b binder) bindStruct(values map[string][]string, prefix string, rv reflect.Value, v *Validator) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		value := rv.Field(i)
+
+		// embedded structs without a name share the parent's fields like
+		// they do with encoding/json, even when the embedded type itself
+		// is unexported
+		if field.Anonymous && field.Tag.Get(b.tag) == "" && field.Tag.Get("json") == "" {
+			switch {
+			case field.Type.Kind() == reflect.Struct:
+				b.bindStruct(values, prefix, value, v)
+				continue
+			case field.Type.Kind() == reflect.Pointer && field.Type.Elem().Kind() == reflect.Struct:
+				if value.IsNil() {
+					// pointers to unexported types cannot be allocated
+					if !field.IsExported() || !b.hasFields(values, prefix, field.Type.Elem()) {
+						continue
+					}
+					value.Set(reflect.New(field.Type.Elem()))
+				}
+				b.bindStruct(values, prefix, value.Elem(), v)
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+		name, ok := b.fieldName(field)
+		if !ok {
+			continue
+		}
+
+		// nested structs are addressed with dotted names,
+		// e.g. <input name="address.postcode">
+		if isNestedStruct(field.Type) {
+			if field.Type.Kind() == reflect.Pointer {
+				if !hasPrefix(values, prefix+name+".") {
+					continue
+				}
+				if value.IsNil() {
+					value.Set(reflect.New(field.Type.Elem()))
+				}
+				value = value.Elem()
+			}
+			b.bindStruct(values, prefix+name+".", value, v.Scope(name))
+			continue
+		}
+
+		raw := values[prefix+name]
+		if b.skipEmpty {
+			raw = slices.DeleteFunc(slices.Clone(raw), func(s string) bool { return s == "" })
+		}
+		if len(raw) == 0 {
+			def, ok := field.Tag.Lookup("default")
+			if !b.defaults || !ok {
+				continue
+			}
+			// defaults are part of the program, not the request
+			dv := NewValidator()
+			b.setField(value, []string{def}, name, dv)
+			if !dv.Valid() {
+				panic(fmt.Sprintf("netio: invalid default %q for field %s", def, field.Name))
+			}
+			continue
+		}
+		b.setField(value, raw, name, v)
+	}
+}
+
+// setField converts raw into value. Slices receive every value, other
+// types the first one.
+func (b binder) setField(value reflect.Value, raw []string, name string, v *Validator) {
+	if value.Kind() == reflect.Slice && value.Type().Elem().Kind() != reflect.Uint8 {
+		if b.split {
+			var items []string
+			for _, s := range raw {
+				for _, item := range strings.Split(s, ",") {
+					if item = strings.TrimSpace(item); item != "" {
+						items = append(items, item)
+					}
+				}
+			}
+			raw = items
+		}
+		slice := reflect.MakeSlice(value.Type(), len(raw), len(raw))
+		failed := false
+		for i, s := range raw {
+			if msg := setValue(slice.Index(i), s); msg != "" {
+				v.Index(name, i).AddError("", msg)
+				failed = true
+			}
+		}
+		if !failed {
+			value.Set(slice)
+		}
+		return
+	}
+
+	if len(raw) == 0 {
+		return
+	}
+	if msg := setValue(value, raw[0]); msg != "" {
+		v.AddError(name, msg)
+	}
+}
+
+// fieldName returns the name of field in the binder's tag, falling back to
+// its json name. The second result is false for fields tagged "-".
+func (b binder) fieldName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get(b.tag)
+	if tag == "-" {
+		return "", false
+	}
+	if name, _, _ := strings.Cut(tag, ","); name != "" {
+		return name, true
+	}
+	return jsonFieldName(field)
+}
+
+// setValue converts s into rv. It returns a client-safe message when s
+// cannot be converted, or an empty string on success.
+func setValue(rv reflect.Value, s string) string {
+	if rv.Kind() == reflect.Pointer {
+		elem := reflect.New(rv.Type().Elem())
+		if msg := setValue(elem.Elem(), s); msg != "" {
+			return msg
+		}
+		rv.Set(elem)
+		return ""
+	}
+
+	if rv.Type() == timeType {
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				rv.Set(reflect.ValueOf(t))
+				return ""
+			}
+		}
+		return "must be a valid date or time"
+	}
+	if rv.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return "must be a valid duration (e.g. 1h30m)"
+		}
+		rv.SetInt(int64(d))
+		return ""
+	}
+	if reflect.PointerTo(rv.Type()).Implements(textUnmarshalerType) {
+		if err := rv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return "is invalid"
+		}
+		return ""
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		rv.SetString(s)
+	case reflect.Bool:
+		// checkboxes send "on" when ticked
+		if s == "on" {
+			rv.SetBool(true)
+			return ""
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return "must be a boolean"
+		}
+		rv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(s), 10, rv.Type().Bits())
+		if err != nil {
+			return conversionMessage(err, "must be an integer")
+		}
+		rv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.TrimSpace(s), 10, rv.Type().Bits())
+		if err != nil {
+			return conversionMessage(err, "must be a non-negative integer")
+		}
+		rv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(strings.TrimSpace(s), rv.Type().Bits())
+		if err != nil {
+			return conversionMessage(err, "must be a number")
+		}
+		rv.SetFloat(n)
+	default:
+		// other kinds (maps, channels, ...) cannot come from a single
+		// string and are left untouched
+	}
+	return ""
+}
+
+// conversionMessage returns the message for a failed number conversion.
+func conversionMessage(err error, invalid string) string {
+	if errors.Is(err, strconv.ErrRange) {
+		return "is out of range"
+	}
+	return invalid
+}
+
+// isNestedStruct reports whether t is a struct (or pointer to one) whose
+// fields are bound individually.
+func isNestedStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && t != timeType &&
+		!reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+// hasFields reports whether values holds a value for any field bindStruct
+// would set on a struct of type t, so embedded pointers are only allocated
+// when needed.
+func (b binder) hasFields(values map[string][]string, prefix string, t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		ft := field.Type
+		if ft.Kind() == reflect.Pointer && ft.Elem().Kind() == reflect.Struct {
+			ft = ft.Elem()
+		}
+		if field.Anonymous && field.Tag.Get(b.tag) == "" && field.Tag.Get("json") == "" &&
+			ft.Kind() == reflect.Struct {
+			if b.hasFields(values, prefix, ft) {
+				return true
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		name, ok := b.fieldName(field)
+		if !ok {
+			continue
+		}
+		if isNestedStruct(field.Type) {
+			if hasPrefix(values, prefix+name+".") {
+				return true
+			}
+			continue
+		}
+		for _, s := range values[prefix+name] {
+			if s != "" || !b.skipEmpty {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasPrefix reports whether values holds a name starting with prefix.
+func hasPrefix(values map[string][]string, prefix string) bool {
+	for name := range values {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
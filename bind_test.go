@@ -0,0 +1,169 @@
+package netio
+
+import (
+	"net/netip"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBinder(t *testing.T) {
+	type address struct {
+		Postcode string `form:"postcode"`
+	}
+	type embedded struct {
+		Source string `json:"source"`
+	}
+	type input struct {
+		embedded
+		Name     string        `form:"name"`
+		Age      int           `json:"age"`
+		Price    float64       `form:"price"`
+		Active   bool          `form:"active"`
+		Count    uint8         `form:"count"`
+		Birthday time.Time     `form:"birthday"`
+		Timeout  time.Duration `form:"timeout"`
+		Tags     []string      `form:"tag"`
+		IDs      []int         `form:"id"`
+		Nickname *string       `form:"nickname"`
+		Addr     netip.Addr    `form:"ip"`
+		Address  address       `form:"address"`
+		Billing  *address      `form:"billing"`
+		Secret   string        `form:"-"`
+	}
+
+	t.Run("conversion", func(t *testing.T) {
+		values := map[string][]string{
+			"source":           {"web"},
+			"name":             {"Alice"},
+			"age":              {"30"},
+			"price":            {"9.95"},
+			"active":           {"on"},
+			"count":            {"7"},
+			"birthday":         {"1990-04-01"},
+			"timeout":          {"1m30s"},
+			"tag":              {"a", "b"},
+			"id":               {"1", "2"},
+			"ip":               {"10.0.0.1"},
+			"address.postcode": {"3000"},
+			"Secret":           {"x"},
+			"-":                {"x"},
+		}
+
+		var in input
+		v := NewValidator()
+		binder{tag: "form"}.bind(values, &in, v)
+		if !v.Valid() {
+			t.Fatalf("bind() errors = %v", v.Errors)
+		}
+
+		want := input{
+			embedded: embedded{Source: "web"},
+			Name:     "Alice",
+			Age:      30,
+			Price:    9.95,
+			Active:   true,
+			Count:    7,
+			Birthday: time.Date(1990, 4, 1, 0, 0, 0, 0, time.UTC),
+			Timeout:  90 * time.Second,
+			Addr:     netip.MustParseAddr("10.0.0.1"),
+			Address:  address{Postcode: "3000"},
+		}
+		if in.Source != want.Source || in.Name != want.Name || in.Age != want.Age ||
+			in.Price != want.Price || in.Active != want.Active || in.Count != want.Count ||
+			!in.Birthday.Equal(want.Birthday) || in.Timeout != want.Timeout ||
+			in.Addr != want.Addr || in.Address != want.Address {
+			t.Errorf("bind() = %+v, want %+v", in, want)
+		}
+		if !slices.Equal(in.Tags, []string{"a", "b"}) || !slices.Equal(in.IDs, []int{1, 2}) {
+			t.Errorf("bind() slices = %v %v, want [a b] [1 2]", in.Tags, in.IDs)
+		}
+		if in.Nickname != nil || in.Billing != nil || in.Secret != "" {
+			t.Errorf("bind() set fields without values: %+v", in)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		values := map[string][]string{
+			"age":              {"thirty"},
+			"price":            {"cheap"},
+			"active":           {"maybe"},
+			"count":            {"300"},
+			"birthday":         {"yesterday"},
+			"timeout":          {"long"},
+			"id":               {"1", "x"},
+			"ip":               {"nope"},
+			"nickname":         {"bob"},
+			"billing.postcode": {"3000"},
+		}
+
+		var in input
+		v := NewValidator()
+		binder{tag: "form"}.bind(values, &in, v)
+
+		want := map[string]string{
+			"age":      "must be an integer",
+			"price":    "must be a number",
+			"active":   "must be a boolean",
+			"count":    "is out of range",
+			"birthday": "must be a valid date or time",
+			"timeout":  "must be a valid duration (e.g. 1h30m)",
+			"id[1]":    "must be an integer",
+			"ip":       "is invalid",
+		}
+		if len(v.Errors) != len(want) {
+			t.Errorf("bind() errors = %v, want %v", v.Errors, want)
+		}
+		for key, msg := range want {
+			if v.Errors[key] != msg {
+				t.Errorf("bind() errors[%q] = %q, want %q", key, v.Errors[key], msg)
+			}
+		}
+		if in.IDs != nil {
+			t.Errorf("bind() IDs = %v, want unset after a failed element", in.IDs)
+		}
+		if in.Nickname == nil || *in.Nickname != "bob" || in.Billing == nil || in.Billing.Postcode != "3000" {
+			t.Errorf("bind() pointers = %v %v, want set", in.Nickname, in.Billing)
+		}
+	})
+
+	t.Run("embedded pointers", func(t *testing.T) {
+		type Base struct {
+			ID int `form:"id"`
+		}
+		type Audit struct {
+			By string `form:"by"`
+		}
+		type withPointers struct {
+			*Base
+			*Audit
+			*embedded
+			Name string `form:"name"`
+		}
+
+		values := map[string][]string{"id": {"3"}, "source": {"web"}, "name": {"Alice"}}
+
+		var in withPointers
+		v := NewValidator()
+		binder{tag: "form"}.bind(values, &in, v)
+		if !v.Valid() {
+			t.Fatalf("bind() errors = %v", v.Errors)
+		}
+		if in.Base == nil || in.ID != 3 || in.Name != "Alice" {
+			t.Errorf("bind() = %+v, want Base.ID 3 and Name Alice", in)
+		}
+		if in.Audit != nil {
+			t.Errorf("bind() Audit = %+v, want nil without a matching value", in.Audit)
+		}
+		if in.embedded != nil {
+			t.Errorf("bind() embedded = %+v, want nil as it cannot be allocated", in.embedded)
+		}
+
+		// an allocated unexported pointer is still filled in
+		in = withPointers{embedded: &embedded{}}
+		binder{tag: "form"}.bind(values, &in, NewValidator())
+		if in.embedded.Source != "web" {
+			t.Errorf("bind() Source = %q, want web", in.embedded.Source)
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package netio
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Clock provides the current time for error response timestamps.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts a function to the Clock interface.
+type ClockFunc func() time.Time
+
+// Now returns f().
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// clock holds the Clock used for timestamps, see SetClock. It is nil for
+// the system clock.
+var clock atomic.Pointer[Clock]
+
+// SetClock replaces the clock used for ErrorResponse timestamps by
+// BuildError, BuildErrorWithValidation, ErrorFallback and everything built
+// on them. Passing nil restores the system clock. It is safe to call at any
+// time; tests running in parallel should use an ErrorBuilder instead.
+//
+// Example:
+//
+//	// golden file tests
+//	fixed := time.Date(2024, 1, 9, 12, 0, 0, 0, time.UTC)
+//	netio.SetClock(netio.ClockFunc(func() time.Time { return fixed }))
+//	defer netio.SetClock(nil)
+func SetClock(c Clock) {
+	if c == nil {
+		clock.Store(nil)
+		return
+	}
+	clock.Store(&c)
+}
+
+// now returns the current time according to the configured clock.
+func now() time.Time {
+	if c := clock.Load(); c != nil {
+		return (*c).Now()
+	}
+	return time.Now()
+}
+
+// ErrorBuilder builds and writes error responses with its own clock and
+// timestamp format instead of the package settings, so golden-file tests
+// running in parallel do not affect each other. The zero value uses the
+// package clock and TimestampDefault.
+//
+// Example:
+//
+//	b := netio.ErrorBuilder{
+//	    Clock:           netio.ClockFunc(func() time.Time { return fixed }),
+//	    TimestampFormat: netio.TimestampRFC3339UTC,
+//	}
+//	b.ErrorWith(w, http.StatusNotFound, netio.ErrorOptions{Request: r})
+type ErrorBuilder struct {
+	// Clock provides the timestamps (defaults to the clock set with SetClock)
+	Clock Clock
+	// TimestampFormat selects how timestamps are encoded
+	TimestampFormat TimestampFormat
+}
+
+// Build creates an ErrorResponse like BuildErrorWithOptions, stamped by the
+// builder's clock and encoded in its timestamp format.
+func (b ErrorBuilder) Build(status int, opts ErrorOptions) ErrorResponse {
+	res := BuildErrorWithOptions(status, opts)
+	if b.Clock != nil {
+		res.Timestamp = b.Clock.Now()
+	}
+	format := b.TimestampFormat
+	res.format = &format
+	return res
+}
+
+// ErrorWith writes an error response like netio.ErrorWith, built with Build.
+func (b ErrorBuilder) ErrorWith(w http.ResponseWriter, code int, opts ErrorOptions) {
+	errorWith(w, code, opts, b.Build)
+}
+
+// WriteErr writes an error response for err like WriteErrRequest, built
+// with Build. r may be nil.
+func (b ErrorBuilder) WriteErr(w http.ResponseWriter, r *http.Request, err error) {
+	if err == nil {
+		return
+	}
+	status, opts := errorOptions(r, err)
+	b.ErrorWith(w, status, opts)
+}
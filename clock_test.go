@@ -0,0 +1,79 @@
+package netio
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetClock(t *testing.T) {
+	fixed := time.Date(2024, 1, 9, 12, 0, 0, 0, time.UTC)
+	SetClock(ClockFunc(func() time.Time { return fixed }))
+	defer SetClock(nil)
+
+	if got := BuildError(http.StatusNotFound).Timestamp; !got.Equal(fixed) {
+		t.Errorf("BuildError() timestamp = %v, want %v", got, fixed)
+	}
+	if got := BuildErrorWithValidation(http.StatusBadRequest, NewValidator()).Timestamp; !got.Equal(fixed) {
+		t.Errorf("BuildErrorWithValidation() timestamp = %v, want %v", got, fixed)
+	}
+	if got := ErrorFallback()["error"].(ErrorResponse).Timestamp; !got.Equal(fixed) {
+		t.Errorf("ErrorFallback() timestamp = %v, want %v", got, fixed)
+	}
+
+	SetClock(nil)
+	if got := BuildError(http.StatusNotFound).Timestamp; got.Equal(fixed) {
+		t.Error("SetClock(nil) did not restore the system clock")
+	}
+}
+
+func TestErrorBuilder(t *testing.T) {
+	tests := []struct {
+		name   string
+		format TimestampFormat
+		want   string
+	}{
+		{"rfc3339 utc", TimestampRFC3339UTC, `"timestamp":"2024-01-09T12:00:00Z"`},
+		{"unix milli", TimestampUnixMilli, `"timestamp":1704801600000`},
+		{"omit", TimestampOmit, `"status":404,"message":"Not Found"}}`},
+	}
+
+	fixed := time.Date(2024, 1, 9, 12, 0, 0, 0, time.UTC)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			b := ErrorBuilder{
+				Clock:           ClockFunc(func() time.Time { return fixed }),
+				TimestampFormat: test.format,
+			}
+			w := httptest.NewRecorder()
+			b.ErrorWith(w, http.StatusNotFound, ErrorOptions{})
+
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, w.Body.Bytes()); err != nil {
+				t.Fatalf("ErrorWith() invalid JSON response: %v", err)
+			}
+			if !strings.Contains(compact.String(), test.want) {
+				t.Errorf("ErrorWith() body = %s, want it to contain %s", compact.String(), test.want)
+			}
+		})
+	}
+
+	// the package settings may change while responses are written
+	t.Run("package settings", func(t *testing.T) {
+		t.Parallel()
+		defer SetClock(nil)
+		defer SetTimestampFormat(TimestampDefault)
+
+		for i := 0; i < 10; i++ {
+			SetClock(ClockFunc(func() time.Time { return fixed }))
+			SetTimestampFormat(TimestampUnixMilli)
+			ErrorWith(httptest.NewRecorder(), http.StatusNotFound, ErrorOptions{})
+		}
+	})
+}
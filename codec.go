@@ -0,0 +1,334 @@
+package netio
+
+import (
+	"encoding"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// ErrUnsupportedData is returned by a codec when the response data cannot
+// be represented in its media type (e.g. CSV data without a list).
+var ErrUnsupportedData = errors.New("data cannot be encoded in the requested media type")
+
+// EncodeFunc encodes response data to w in a specific media type.
+type EncodeFunc func(w io.Writer, data Envelope) error
+
+// codec pairs a media type with its encoder.
+type codec struct {
+	mediaType string
+	encode    EncodeFunc
+	// builtin is false once the codec has been replaced with RegisterCodec
+	builtin bool
+}
+
+var (
+	codecsMu sync.RWMutex
+	// codecs are kept in registration order, which is also the order of
+	// preference when the client accepts several media types equally
+	codecs = []codec{
+		// JSON is written with Write so it follows the output mode, see Respond
+		{"application/json", nil, true},
+		{"application/xml", encodeXML, true},
+		{"text/csv", encodeCSV, true},
+		{NDJSONContentType, encodeNDJSON, true},
+	}
+)
+
+// RegisterCodec makes an encoder available to netio.Respond for the given
+// media type (e.g. "application/yaml"). Registering a media type that
+// already exists replaces its encoder while keeping its preference order.
+//
+// The built-in codecs are application/json, application/xml, text/csv and
+// application/x-ndjson.
+//
+// Example:
+//
+//	netio.RegisterCodec("application/yaml", func(w io.Writer, data netio.Envelope) error {
+//	    return yaml.NewEncoder(w).Encode(data)
+//	})
+func RegisterCodec(mediaType string, enc EncodeFunc) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+
+	mediaType = strings.ToLower(mediaType)
+	for i := range codecs {
+		if codecs[i].mediaType == mediaType {
+			codecs[i].encode = enc
+			codecs[i].builtin = false
+			return
+		}
+	}
+	codecs = append(codecs, codec{mediaType, enc, false})
+}
+
+// registeredCodecs returns a snapshot of the codec registry.
+func registeredCodecs() []codec {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	return slices.Clone(codecs)
+}
+
+// encodeNDJSON writes one JSON value per line. If data holds a single list
+// each element becomes a line, otherwise data is written as one line.
+func encodeNDJSON(w io.Writer, data Envelope) error {
+	enc := json.NewEncoder(w)
+
+	list, ok := singleList(data)
+	if !ok {
+		return enc.Encode(data)
+	}
+	for i := 0; i < list.Len(); i++ {
+		if err := enc.Encode(list.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeCSV writes the single list held by data as CSV with a header row.
+// Elements may be structs (columns named by their json tags), maps with
+// string keys (columns sorted by key) or string slices (written as is).
+func encodeCSV(w io.Writer, data Envelope) error {
+	list, ok := singleList(data)
+	if !ok {
+		return fmt.Errorf("%w: csv requires an envelope with a single list", ErrUnsupportedData)
+	}
+
+	cw := csv.NewWriter(w)
+	var header []string
+
+	for i := 0; i < list.Len(); i++ {
+		row := list.Index(i)
+		for row.Kind() == reflect.Pointer || row.Kind() == reflect.Interface {
+			row = row.Elem()
+		}
+
+		var record []string
+		switch {
+		case row.Kind() == reflect.Struct:
+			if header == nil {
+				header = structColumns(row.Type())
+				if err := cw.Write(header); err != nil {
+					return err
+				}
+			}
+			record = structRecord(row)
+
+		case row.Kind() == reflect.Map && row.Type().Key().Kind() == reflect.String:
+			if header == nil {
+				for _, key := range row.MapKeys() {
+					header = append(header, key.String())
+				}
+				slices.Sort(header)
+				if err := cw.Write(header); err != nil {
+					return err
+				}
+			}
+			for _, col := range header {
+				record = append(record, csvField(row.MapIndex(reflect.ValueOf(col).Convert(row.Type().Key()))))
+			}
+
+		case row.Kind() == reflect.Slice && row.Type().Elem().Kind() == reflect.String:
+			for j := 0; j < row.Len(); j++ {
+				record = append(record, row.Index(j).String())
+			}
+
+		default:
+			return fmt.Errorf("%w: csv cannot encode %s rows", ErrUnsupportedData, row.Kind())
+		}
+
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// structColumns returns the column names of a struct row.
+func structColumns(t reflect.Type) []string {
+	var cols []string
+	for i := 0; i < t.NumField(); i++ {
+		if !t.Field(i).IsExported() {
+			continue
+		}
+		if name, ok := jsonFieldName(t.Field(i)); ok {
+			cols = append(cols, name)
+		}
+	}
+	return cols
+}
+
+// structRecord returns the values of a struct row in column order.
+func structRecord(row reflect.Value) []string {
+	var record []string
+	t := row.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if !t.Field(i).IsExported() {
+			continue
+		}
+		if _, ok := jsonFieldName(t.Field(i)); ok {
+			record = append(record, csvField(row.Field(i)))
+		}
+	}
+	return record
+}
+
+// csvField formats a single CSV cell. Missing and nil values are empty.
+func csvField(v reflect.Value) string {
+	if !v.IsValid() {
+		return ""
+	}
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// singleList returns the value of data when it holds exactly one entry
+// and that entry is a slice or array.
+func singleList(data Envelope) (reflect.Value, bool) {
+	if len(data) != 1 {
+		return reflect.Value{}, false
+	}
+	for _, value := range data {
+		rv := reflect.ValueOf(value)
+		if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+			return rv, true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// encodeXML writes data as an XML document with a <response> root element.
+// Envelope keys become child elements. Maps with string keys, structs
+// (using their json field names) and slices are expanded recursively
+// (slice elements are written as <item>), other values are encoded with
+// encoding/xml. Keys that are not valid XML names (e.g. "1st place") are
+// written as <entry key="1st place">.
+func encodeXML(w io.Writer, data Envelope) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "\t")
+	if err := encodeXMLValue(enc, "response", reflect.ValueOf(map[string]any(data))); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// encodeXMLValue writes rv as an element called name.
+func encodeXMLValue(enc *xml.Encoder, name string, rv reflect.Value) error {
+	for rv.Kind() == reflect.Interface || rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if !isXMLName(name) {
+		// never let data decide the markup
+		start = xml.StartElement{
+			Name: xml.Name{Local: "entry"},
+			Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: name}},
+		}
+	}
+
+	switch {
+	case rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String:
+		keys := make([]string, 0, rv.Len())
+		for _, key := range rv.MapKeys() {
+			keys = append(keys, key.String())
+		}
+		slices.Sort(keys)
+
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		for _, key := range keys {
+			value := rv.MapIndex(reflect.ValueOf(key).Convert(rv.Type().Key()))
+			if err := encodeXMLValue(enc, key, value); err != nil {
+				return err
+			}
+		}
+		return enc.EncodeToken(start.End())
+
+	case rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8:
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		for i := 0; i < rv.Len(); i++ {
+			if err := encodeXMLValue(enc, "item", rv.Index(i)); err != nil {
+				return err
+			}
+		}
+		return enc.EncodeToken(start.End())
+
+	case rv.Kind() == reflect.Struct && !implementsMarshaler(rv.Type()):
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		t := rv.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if !t.Field(i).IsExported() {
+				continue
+			}
+			if fieldName, ok := jsonFieldName(t.Field(i)); ok {
+				if err := encodeXMLValue(enc, fieldName, rv.Field(i)); err != nil {
+					return err
+				}
+			}
+		}
+		return enc.EncodeToken(start.End())
+
+	default:
+		return enc.EncodeElement(rv.Interface(), start)
+	}
+}
+
+// isXMLName reports whether s can be used as an XML element name as is.
+// Colons are rejected as they would be read as a namespace prefix.
+func isXMLName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		switch {
+		case unicode.IsLetter(c) || c == '_':
+		case i > 0 && (unicode.IsDigit(c) || c == '-' || c == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// implementsMarshaler reports whether t controls its own XML or text
+// encoding (e.g. time.Time), in which case it is not expanded field by field.
+func implementsMarshaler(t reflect.Type) bool {
+	xmlMarshaler := reflect.TypeFor[xml.Marshaler]()
+	textMarshaler := reflect.TypeFor[encoding.TextMarshaler]()
+	return t.Implements(xmlMarshaler) || t.Implements(textMarshaler) ||
+		reflect.PointerTo(t).Implements(xmlMarshaler) || reflect.PointerTo(t).Implements(textMarshaler)
+}
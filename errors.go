@@ -1,10 +1,75 @@
 package netio
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
+	"sync/atomic"
 	"time"
 )
 
+// ErrorFormat selects the response body produced by netio.Error.
+type ErrorFormat int
+
+const (
+	// ErrorFormatEnvelope writes an ErrorResponse wrapped in an Envelope
+	// under the key given to netio.Error. This is the default.
+	ErrorFormatEnvelope ErrorFormat = iota
+	// ErrorFormatProblem writes RFC 9457 problem details using
+	// application/problem+json. The key given to netio.Error is ignored.
+	ErrorFormatProblem
+)
+
+// errorFormat is the format used by netio.Error, see SetErrorFormat.
+var errorFormat = ErrorFormatEnvelope
+
+// SetErrorFormat changes the response body produced by netio.Error for the
+// whole package.
+//
+// Example:
+//
+//	func main() {
+//	    // API gateway expects application/problem+json
+//	    netio.SetErrorFormat(netio.ErrorFormatProblem)
+//	    ...
+//	}
+func SetErrorFormat(f ErrorFormat) {
+	errorFormat = f
+}
+
+// TimestampFormat selects how ErrorResponse.Timestamp is encoded.
+type TimestampFormat int
+
+const (
+	// TimestampDefault encodes the timestamp like time.Time does
+	// (RFC 3339 with nanoseconds, in the clock's time zone). This is the default.
+	TimestampDefault TimestampFormat = iota
+	// TimestampRFC3339UTC encodes the timestamp as RFC 3339 in UTC with
+	// second precision (e.g. "2024-01-09T12:00:00Z").
+	TimestampRFC3339UTC
+	// TimestampUnixMilli encodes the timestamp as a number of milliseconds
+	// since the Unix epoch (e.g. 1704801600000).
+	TimestampUnixMilli
+	// TimestampOmit leaves the timestamp out of the response.
+	TimestampOmit
+)
+
+// timestampFormat holds the TimestampFormat used for ErrorResponse
+// timestamps, see SetTimestampFormat. It is read while encoding responses,
+// so it is atomic.
+var timestampFormat atomic.Int32
+
+// SetTimestampFormat changes how ErrorResponse timestamps are encoded for
+// the whole package. It is safe to call at any time; use an ErrorBuilder
+// for a format that only applies to some responses.
+//
+// Example:
+//
+//	netio.SetTimestampFormat(netio.TimestampUnixMilli)
+func SetTimestampFormat(f TimestampFormat) {
+	timestampFormat.Store(int32(f))
+}
+
 // ErrorResponse represents a standardized error response structure for HTTP APIs.
 // It includes the status code, message, optional validation errors, and timestamp
 // of when the error occurred.
@@ -12,13 +77,52 @@ type ErrorResponse struct {
 	// Status represents the HTTP status code
 	Status int `json:"status"`
 	// Message contains the HTTP status text (e.g., "Bad Request" for 400)
+	// unless a custom message was given
 	Message string `json:"message"`
+	// Detail optionally explains this particular occurrence of the error
+	Detail string `json:"detail,omitempty"`
+	// Code is an optional machine-readable error code (e.g. "user_not_found")
+	Code string `json:"code,omitempty"`
+	// Meta holds optional additional fields for the client (e.g. {"retry_after": 30})
+	Meta map[string]any `json:"meta,omitempty"`
 	// ValidationErrors holds validation-specific errors when present.
 	// This field works in conjunction with netio.Validator to provide
 	// detailed validation feedback to API clients.
 	ValidationErrors any `json:"validation,omitempty"`
+	// RequestID is the ID of the request being answered, if known (see RequestID)
+	RequestID string `json:"request_id,omitempty"`
 	// Timestamp indicates when the error occurred
 	Timestamp time.Time `json:"timestamp"`
+
+	// format overrides the package TimestampFormat, see ErrorBuilder
+	format *TimestampFormat
+}
+
+// MarshalJSON implements json.Marshaler, encoding Timestamp in the format
+// selected by SetTimestampFormat, or by the ErrorBuilder that built e.
+func (e ErrorResponse) MarshalJSON() ([]byte, error) {
+	// plain has no MarshalJSON method, and its Timestamp field is hidden
+	// by the shallower one below
+	type plain ErrorResponse
+	out := struct {
+		plain
+		Timestamp any `json:"timestamp,omitempty"`
+	}{plain: plain(e)}
+
+	format := TimestampFormat(timestampFormat.Load())
+	if e.format != nil {
+		format = *e.format
+	}
+	switch format {
+	case TimestampRFC3339UTC:
+		out.Timestamp = e.Timestamp.UTC().Format(time.RFC3339)
+	case TimestampUnixMilli:
+		out.Timestamp = e.Timestamp.UnixMilli()
+	case TimestampOmit:
+	default:
+		out.Timestamp = e.Timestamp
+	}
+	return json.Marshal(out)
 }
 
 // ErrorFallback returns a generic error response envelope used when primary
@@ -29,7 +133,7 @@ func ErrorFallback() Envelope {
 		"error": ErrorResponse{
 			Status:    http.StatusInternalServerError,
 			Message:   http.StatusText(http.StatusInternalServerError),
-			Timestamp: time.Now(),
+			Timestamp: now(),
 		},
 	}
 }
@@ -40,21 +144,123 @@ func BuildError(status int) ErrorResponse {
 	return ErrorResponse{
 		Status:    status,
 		Message:   http.StatusText(status),
-		Timestamp: time.Now(),
+		Timestamp: now(),
 	}
 }
 
 // BuildErrorWithValidation creates a new ErrorResponse that includes validation errors.
 // It combines the HTTP status code with validation errors from a Validator instance.
+// Each field holds a single message, or an array of messages when the Validator
+// was created with WithAllErrors.
 func BuildErrorWithValidation(status int, v *Validator) ErrorResponse {
 	return ErrorResponse{
 		Status:           status,
 		Message:          http.StatusText(status),
-		ValidationErrors: v.Errors,
-		Timestamp:        time.Now(),
+		ValidationErrors: v.messages(),
+		Timestamp:        now(),
 	}
 }
 
+// ErrorOptions customises the error response written by ErrorWith and
+// built by BuildErrorWithOptions. All fields are optional.
+type ErrorOptions struct {
+	// Key is the JSON key wrapping the error in the response envelope (defaults to "error")
+	Key string
+	// Message replaces the standard HTTP status text
+	Message string
+	// Detail explains this particular occurrence of the error
+	Detail string
+	// Code is a machine-readable error code
+	Code string
+	// Meta holds additional fields for the client
+	Meta map[string]any
+	// Validator adds validation errors to the response
+	Validator *Validator
+	// Request is the request being answered. It is used for logging and
+	// provides the response's request ID (see RequestID).
+	Request *http.Request
+	// Cause is the underlying error. It is logged but never sent to the client.
+	Cause error
+}
+
+// BuildErrorWithMessage creates a new ErrorResponse with the specified HTTP
+// status code and a custom message instead of the standard status text.
+// An empty message falls back to the status text.
+func BuildErrorWithMessage(status int, message string) ErrorResponse {
+	return BuildErrorWithOptions(status, ErrorOptions{Message: message})
+}
+
+// BuildErrorWithOptions creates a new ErrorResponse with the specified HTTP
+// status code, customised by opts. The Key option is ignored because it only
+// applies to the response envelope.
+func BuildErrorWithOptions(status int, opts ErrorOptions) ErrorResponse {
+	var res ErrorResponse
+	if opts.Validator != nil {
+		res = BuildErrorWithValidation(status, opts.Validator)
+	} else {
+		res = BuildError(status)
+	}
+	if opts.Message != "" {
+		res.Message = opts.Message
+	}
+	res.Detail = opts.Detail
+	res.Code = opts.Code
+	res.Meta = opts.Meta
+	if opts.Request != nil {
+		res.RequestID = requestID(opts.Request)
+	}
+	return res
+}
+
+// ErrorWith writes a JSON error response like Error, customised by opts.
+// It accepts a custom message, a detail string, a machine-readable code and
+// arbitrary metadata in addition to validation errors.
+//
+// Parameters:
+//   - w: The http.ResponseWriter to write the response to
+//   - code: The HTTP status code (automatically corrected to 500 if invalid)
+//   - opts: Optional response customisation
+//
+// Example:
+//
+//	netio.ErrorWith(w, http.StatusNotFound, netio.ErrorOptions{
+//	    Message: "order not found",
+//	    Detail:  "order 42 does not exist or belongs to another account",
+//	    Code:    "order_not_found",
+//	    Meta:    map[string]any{"order_id": 42},
+//	})
+//
+// The JSON response:
+//
+//	{
+//	    "error": {
+//	        "status": 404,
+//	        "message": "order not found",
+//	        "detail": "order 42 does not exist or belongs to another account",
+//	        "code": "order_not_found",
+//	        "meta": {
+//	            "order_id": 42
+//	        },
+//	        "timestamp": "2024-01-09T12:00:00Z"
+//	    }
+//	}
+func ErrorWith(w http.ResponseWriter, code int, opts ErrorOptions) {
+	errorWith(w, code, opts, BuildErrorWithOptions)
+}
+
+// errorWith implements ErrorWith, building the response with build.
+func errorWith(w http.ResponseWriter, code int, opts ErrorOptions, build func(int, ErrorOptions) ErrorResponse) {
+	// handle invalid code and empty key
+	if code < 100 || code > 599 {
+		code = http.StatusInternalServerError
+	}
+	if opts.Key == "" {
+		opts.Key = "error"
+	}
+	err := writeErrorResponse(w, opts.Key, build(code, opts), opts.Validator)
+	logError(code, opts, err)
+}
+
 // Error writes a JSON error response to the provided http.ResponseWriter.
 // It handles various error scenarios and provides a consistent error structure
 // across your API endpoints.
@@ -64,7 +270,13 @@ func BuildErrorWithValidation(status int, v *Validator) ErrorResponse {
 //   - Timestamp of when the error occurred
 //   - Optional validation errors from netio.Validator
 //
-// If writing the response fails, it falls back to a generic 500 Internal Server Error.
+// If the response cannot be encoded, it falls back to a generic 500 Internal Server Error.
+//
+// Use ErrorWith to send a custom message, detail, machine-readable code or
+// additional metadata.
+//
+// When SetErrorFormat(ErrorFormatProblem) has been called, Error writes RFC 9457
+// problem details through netio.Problem instead of the envelope shown below.
 //
 // Parameters:
 //   - w: The http.ResponseWriter to write the response to
@@ -115,24 +327,67 @@ func BuildErrorWithValidation(status int, v *Validator) ErrorResponse {
 //	    }
 //	}
 func Error(w http.ResponseWriter, key string, code int, v *Validator) {
-	// handle invalid code and empty key
-	if code < 100 || code > 599 {
-		code = http.StatusInternalServerError
-	}
-	if key == "" {
-		key = "error"
-	}
-	// build error response
-	var res ErrorResponse
-	if v != nil {
-		res = BuildErrorWithValidation(code, v)
-	} else {
-		res = BuildError(code)
+	ErrorWith(w, code, ErrorOptions{Key: key, Validator: v})
+}
+
+// ErrorRequest writes a JSON error response like Error. r is the request
+// being answered: its request ID (see RequestID) is added to the response
+// as "request_id" and it is passed to the error logger.
+//
+// Example:
+//
+//	if !v.Valid() {
+//	    netio.ErrorRequest(w, r, "error", http.StatusUnprocessableEntity, v)
+//	    return
+//	}
+func ErrorRequest(w http.ResponseWriter, r *http.Request, key string, code int, v *Validator) {
+	ErrorWith(w, code, ErrorOptions{Key: key, Validator: v, Request: r})
+}
+
+// writeErrorResponse writes res in the format selected by SetErrorFormat.
+// v is the Validator res was built from, if any. It returns any error that
+// occurred while writing, including encoding failures that triggered the
+// generic fallback response.
+func writeErrorResponse(w http.ResponseWriter, key string, res ErrorResponse, v *Validator) error {
+	// problem details do not use an envelope
+	if errorFormat == ErrorFormatProblem {
+		var p ProblemDetails
+		if v != nil {
+			p = BuildProblemWithValidation(res.Status, v)
+		} else {
+			p = BuildProblem(res.Status)
+		}
+		p.Title = res.Message
+		p.Detail = res.Detail
+		if res.Code != "" || res.RequestID != "" || len(res.Meta) > 0 {
+			// Meta goes first so it cannot replace the members set by
+			// netio itself ("errors", "code" and "request_id")
+			ext := make(map[string]any, len(res.Meta)+len(p.Extensions)+2)
+			for key, value := range res.Meta {
+				ext[key] = value
+			}
+			for key, value := range p.Extensions {
+				ext[key] = value
+			}
+			if res.Code != "" {
+				ext["code"] = res.Code
+			}
+			if res.RequestID != "" {
+				ext["request_id"] = res.RequestID
+			}
+			p.Extensions = ext
+		}
+		return writeProblem(w, p)
 	}
+
 	// wrap error with envelope
 	env := Envelope{key: res}
-	if err := Write(w, code, env, nil); err != nil {
-		// if failed to write, fallback to writing generic error
-		Write(w, http.StatusInternalServerError, ErrorFallback(), nil)
+	err := Write(w, res.Status, env, nil)
+	if errors.Is(err, ErrNetioMarshalFailure) {
+		// nothing has been sent yet, fallback to writing generic error
+		if fallbackErr := Write(w, http.StatusInternalServerError, ErrorFallback(), nil); fallbackErr != nil {
+			return errors.Join(err, fallbackErr)
+		}
 	}
+	return err
 }
@@ -0,0 +1,126 @@
+package netio
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBuildErrorWithOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        ErrorOptions
+		wantMessage string
+	}{
+		{"default message", ErrorOptions{}, "Not Found"},
+		{"custom message", ErrorOptions{Message: "order not found"}, "order not found"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res := BuildErrorWithOptions(http.StatusNotFound, tc.opts)
+			if res.Status != http.StatusNotFound {
+				t.Errorf("BuildErrorWithOptions() status = %v, want 404", res.Status)
+			}
+			if res.Message != tc.wantMessage {
+				t.Errorf("BuildErrorWithOptions() message = %q, want %q", res.Message, tc.wantMessage)
+			}
+		})
+	}
+
+	if got := BuildErrorWithMessage(http.StatusNotFound, "").Message; got != "Not Found" {
+		t.Errorf("BuildErrorWithMessage() empty message = %q, want status text", got)
+	}
+}
+
+func TestErrorWith(t *testing.T) {
+	opts := ErrorOptions{
+		Key:     "failure",
+		Message: "order not found",
+		Detail:  "order 42 does not exist",
+		Code:    "order_not_found",
+		Meta:    map[string]any{"order_id": 42},
+	}
+
+	t.Run("envelope", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		ErrorWith(w, http.StatusNotFound, opts)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("ErrorWith() code = %v, want 404", w.Code)
+		}
+
+		var got map[string]ErrorResponse
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatalf("ErrorWith() invalid JSON response: %v", err)
+		}
+		res, ok := got["failure"]
+		if !ok {
+			t.Fatalf("ErrorWith() response = %v, want key failure", got)
+		}
+		if res.Message != opts.Message || res.Detail != opts.Detail || res.Code != opts.Code {
+			t.Errorf("ErrorWith() response = %+v, want options applied", res)
+		}
+		if res.Meta["order_id"] != float64(42) {
+			t.Errorf("ErrorWith() meta = %v, want order_id 42", res.Meta)
+		}
+	})
+
+	t.Run("problem", func(t *testing.T) {
+		SetErrorFormat(ErrorFormatProblem)
+		defer SetErrorFormat(ErrorFormatEnvelope)
+
+		w := httptest.NewRecorder()
+		ErrorWith(w, http.StatusNotFound, opts)
+
+		var got map[string]any
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatalf("ErrorWith() invalid JSON response: %v", err)
+		}
+		want := map[string]any{
+			"title":    "order not found",
+			"detail":   "order 42 does not exist",
+			"code":     "order_not_found",
+			"order_id": float64(42),
+		}
+		for key, value := range want {
+			if got[key] != value {
+				t.Errorf("ErrorWith() %s = %v, want %v", key, got[key], value)
+			}
+		}
+	})
+}
+
+func TestSetTimestampFormat(t *testing.T) {
+	fixed := time.Date(2024, 1, 9, 12, 0, 0, 500, time.FixedZone("AEDT", 11*60*60))
+	SetClock(ClockFunc(func() time.Time { return fixed }))
+	defer SetClock(nil)
+
+	tests := []struct {
+		name   string
+		format TimestampFormat
+		want   string
+	}{
+		{"default", TimestampDefault, `{"status":404,"message":"Not Found","timestamp":"2024-01-09T12:00:00.0000005+11:00"}`},
+		{"rfc3339 utc", TimestampRFC3339UTC, `{"status":404,"message":"Not Found","timestamp":"2024-01-09T01:00:00Z"}`},
+		{"unix millis", TimestampUnixMilli, `{"status":404,"message":"Not Found","timestamp":1704762000000}`},
+		{"omit", TimestampOmit, `{"status":404,"message":"Not Found"}`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			SetTimestampFormat(tc.format)
+			defer SetTimestampFormat(TimestampDefault)
+
+			got, err := json.Marshal(BuildError(http.StatusNotFound))
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("json.Marshal() = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
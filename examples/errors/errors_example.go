@@ -22,8 +22,8 @@ func registerHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// read request body into input struct
-	if err := netio.Read(w, r, &input); err != nil {
-		netio.Error(w, "error", http.StatusBadRequest, nil)
+	// on failure an error response has already been written
+	if err := netio.ReadOrError(w, r, &input); err != nil {
 		return
 	}
 
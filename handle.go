@@ -0,0 +1,139 @@
+package netio
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// HTTPError is implemented by errors that know which HTTP status code they
+// should be reported with. Handlers built with Handle translate any error in
+// the returned chain that implements HTTPError into a netio.Error response
+// with that status; other errors become 500 Internal Server Error.
+//
+// *StatusError (see NewHTTPError), *ReadError and *ValidationError
+// implement HTTPError.
+type HTTPError interface {
+	error
+	StatusCode() int
+}
+
+// validationCarrier is implemented by errors that can describe themselves
+// as field level validation messages in error responses.
+type validationCarrier interface {
+	validator() *Validator
+}
+
+// handleConfig holds the settings of a handler created by Handle.
+type handleConfig struct {
+	key    string
+	status int
+	reader *Reader
+}
+
+// HandleOption configures a handler created by Handle.
+type HandleOption func(*handleConfig)
+
+// WithEnvelopeKey sets the Envelope key the handler's result is written
+// under. The default is "data".
+func WithEnvelopeKey(key string) HandleOption {
+	return func(c *handleConfig) {
+		c.key = key
+	}
+}
+
+// WithStatus sets the status code written on success. The default is
+// 200 OK.
+func WithStatus(code int) HandleOption {
+	return func(c *handleConfig) {
+		c.status = code
+	}
+}
+
+// WithReader sets the Reader used to decode request bodies. The default
+// behaves like netio.Read.
+func WithReader(rd *Reader) HandleOption {
+	return func(c *handleConfig) {
+		c.reader = rd
+	}
+}
+
+// Handle adapts a typed function into an http.Handler, removing the
+// read-validate-write boilerplate shared by most JSON endpoints.
+//
+// For every request the handler:
+//  1. decodes the body into a new In using Read (skipped for GET and HEAD
+//     and for requests without a body, which leave In as its zero value)
+//  2. calls In's Validate method if it implements Validatable, responding
+//     with 422 Unprocessable Entity and the validation errors if it fails
+//  3. calls fn with the request context
+//  4. writes the result with Write, wrapped in an Envelope under the
+//     configured key ("data" by default)
+//
+// Read failures are reported like ReadOrError and errors returned by fn are
+// reported with WriteErr: the first error in the chain implementing
+// HTTPError decides the status code, and any other error is reported as
+// 500 Internal Server Error without exposing its message.
+//
+// Example:
+//
+//	type createUserInput struct {
+//	    Name  string `json:"name"`
+//	    Email string `json:"email"`
+//	}
+//
+//	func (in createUserInput) Validate(v *netio.Validator) {
+//	    v.Check(in.Name != "", "name", "must be provided")
+//	    v.Check(netio.Matches(in.Email, netio.EmailRX), "email", "must be a valid email")
+//	}
+//
+//	mux.Handle("POST /users", netio.Handle(func(ctx context.Context, in createUserInput) (User, error) {
+//	    return store.CreateUser(ctx, in.Name, in.Email)
+//	}, netio.WithEnvelopeKey("user"), netio.WithStatus(http.StatusCreated)))
+func Handle[In, Out any](fn func(ctx context.Context, in In) (Out, error), opts ...HandleOption) http.Handler {
+	cfg := handleConfig{
+		key:    "data",
+		status: http.StatusOK,
+		reader: defaultReader,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var in In
+
+		if hasBody(r) {
+			if err := cfg.reader.ReadOrError(w, r, &in); err != nil {
+				return
+			}
+		}
+
+		if err := validate(&in); err != nil {
+			writeErr(w, r, err)
+			return
+		}
+
+		out, err := fn(r.Context(), in)
+		if err != nil {
+			writeErr(w, r, err)
+			return
+		}
+
+		if err := Write(w, cfg.status, Envelope{cfg.key: out}, nil, WithRequest(r)); err != nil {
+			if errors.Is(err, ErrNetioMarshalFailure) {
+				ErrorWith(w, http.StatusInternalServerError, ErrorOptions{Request: r, Cause: err})
+			}
+		}
+	})
+}
+
+// hasBody reports whether Handle should decode the body of r. GET and HEAD
+// bodies are never read, and bodiless requests such as a POST that only
+// triggers an action are not an error.
+func hasBody(r *http.Request) bool {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return false
+	}
+	return r.Body != nil && r.Body != http.NoBody && r.ContentLength != 0
+}
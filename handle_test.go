@@ -0,0 +1,108 @@
+package netio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type greetInput struct {
+	Name string `json:"name"`
+}
+
+func (in greetInput) Validate(v *Validator) {
+	v.Check(in.Name != "", "name", "must be provided")
+}
+
+type greetOutput struct {
+	Greeting string `json:"greeting"`
+}
+
+// teapotError is a test error implementing HTTPError.
+type teapotError struct{}
+
+func (teapotError) Error() string   { return "internal detail" }
+func (teapotError) StatusCode() int { return http.StatusTeapot }
+
+func TestHandle(t *testing.T) {
+	h := Handle(func(ctx context.Context, in greetInput) (greetOutput, error) {
+		switch in.Name {
+		case "teapot":
+			return greetOutput{}, fmt.Errorf("brewing: %w", teapotError{})
+		case "boom":
+			return greetOutput{}, errors.New("database password is hunter2")
+		}
+		return greetOutput{Greeting: "hello " + in.Name}, nil
+	}, WithEnvelopeKey("result"), WithStatus(http.StatusCreated))
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantBody   string
+		notBody    string
+	}{
+		{"success", `{"name": "jack"}`, http.StatusCreated, `"result"`, ""},
+		{"read failure", `{"name": }`, http.StatusBadRequest, `"body"`, ""},
+		{"validation failure", `{"name": ""}`, http.StatusUnprocessableEntity, `"must be provided"`, ""},
+		{"http error", `{"name": "teapot"}`, http.StatusTeapot, `"I'm a teapot"`, "internal detail"},
+		{"internal error", `{"name": "boom"}`, http.StatusInternalServerError, `"Internal Server Error"`, "hunter2"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.body))
+			w := httptest.NewRecorder()
+
+			h.ServeHTTP(w, r)
+
+			if w.Code != test.wantStatus {
+				t.Errorf("Handle() code = %v, want %v", w.Code, test.wantStatus)
+			}
+			if !strings.Contains(w.Body.String(), test.wantBody) {
+				t.Errorf("Handle() body = %s, want it to contain %s", w.Body.String(), test.wantBody)
+			}
+			if test.notBody != "" && strings.Contains(w.Body.String(), test.notBody) {
+				t.Errorf("Handle() body leaked %q", test.notBody)
+			}
+			if !json.Valid(w.Body.Bytes()) {
+				t.Error("Handle() invalid JSON response")
+			}
+		})
+	}
+}
+
+func TestHandle_SkipsEmptyBody(t *testing.T) {
+	h := Handle(func(ctx context.Context, in struct{}) (string, error) {
+		return "ok", nil
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		body   io.Reader
+	}{
+		{"get", http.MethodGet, nil},
+		{"post without body", http.MethodPost, nil},
+		{"delete without body", http.MethodDelete, nil},
+		{"post with empty body", http.MethodPost, strings.NewReader("")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(test.method, "/", test.body)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != http.StatusOK {
+				t.Errorf("Handle() code = %v, want %v, body = %s", w.Code, http.StatusOK, w.Body.String())
+			}
+		})
+	}
+}
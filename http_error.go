@@ -0,0 +1,209 @@
+package netio
+
+import (
+	"errors"
+	"net/http"
+)
+
+// StatusError is an error that carries the HTTP status code, an optional
+// machine-readable code and a client-facing message, plus an optional cause
+// for logging. It implements HTTPError and is created with NewHTTPError.
+//
+// A StatusError matches the sentinel it was created from with errors.Is,
+// also after Wrap. Two StatusErrors with a non-empty Code also match when
+// their status and code are equal.
+type StatusError struct {
+	// Status is the HTTP status code
+	Status int
+	// Code is an optional machine-readable error code (e.g. "user_not_found")
+	Code string
+	// Message is the client-facing description. It is sent to clients for
+	// 4xx statuses and never for 5xx statuses.
+	Message string
+	// Cause is the underlying error, if any. It is never sent to clients.
+	Cause error
+
+	// origin is the StatusError Wrap was called on, used by Is
+	origin *StatusError
+}
+
+// NewHTTPError creates a StatusError with the given status code,
+// machine-readable code and client-facing message.
+//
+// Example:
+//
+//	var ErrUserNotFound = netio.NewHTTPError(http.StatusNotFound, "user_not_found", "user does not exist")
+//
+//	func (s *Store) User(ctx context.Context, id int) (User, error) {
+//	    ...
+//	    if errors.Is(err, sql.ErrNoRows) {
+//	        return User{}, ErrUserNotFound.Wrap(err)
+//	    }
+//	}
+//
+//	// in a handler
+//	if err != nil {
+//	    netio.WriteErr(w, err)
+//	    return
+//	}
+func NewHTTPError(status int, code, message string) *StatusError {
+	return &StatusError{
+		Status:  status,
+		Code:    code,
+		Message: message,
+	}
+}
+
+// Wrap returns a copy of e with err as its cause. e itself is not modified,
+// so Wrap is safe to call on package level sentinels.
+func (e *StatusError) Wrap(err error) *StatusError {
+	wrapped := *e
+	wrapped.Cause = err
+	wrapped.origin = e.sentinel()
+	return &wrapped
+}
+
+// sentinel returns the StatusError e was created from.
+func (e *StatusError) sentinel() *StatusError {
+	if e.origin != nil {
+		return e.origin
+	}
+	return e
+}
+
+// Error implements the error interface. The result includes the cause and
+// is meant for logs, not for clients.
+func (e *StatusError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.Status)
+	}
+	if e.Cause != nil {
+		return msg + ": " + e.Cause.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the cause of the error.
+func (e *StatusError) Unwrap() error {
+	return e.Cause
+}
+
+// Is reports whether target is a *StatusError created from the same
+// sentinel as e, or one with the same status and non-empty code.
+func (e *StatusError) Is(target error) bool {
+	t, ok := target.(*StatusError)
+	if !ok {
+		return false
+	}
+	if e.sentinel() == t.sentinel() {
+		return true
+	}
+	return e.Code != "" && e.Status == t.Status && e.Code == t.Code
+}
+
+// StatusCode returns the HTTP status code of the error.
+func (e *StatusError) StatusCode() int {
+	return e.Status
+}
+
+// ErrorCode returns the machine-readable code of the error.
+func (e *StatusError) ErrorCode() string {
+	return e.Code
+}
+
+// clientMessage returns the message sent to clients.
+func (e *StatusError) clientMessage() string {
+	return e.Message
+}
+
+// WriteErr writes an error response for err with netio.Error's format.
+//
+// The error chain is searched for an HTTPError, whose status code is used
+// for the response. Its machine-readable code (see StatusError.ErrorCode) and
+// client-facing message are included for 4xx statuses, and errors such as
+// *ReadError and *ValidationError add their field level messages to the
+// validation map. Errors without an HTTPError in their chain, and all 5xx
+// errors, are reported with only the standard status text so internal
+// details never leak. err itself is only passed to the error logger (see
+// SetErrorLogger). WriteErr does nothing if err is nil.
+//
+// Parameters:
+//   - w: The http.ResponseWriter to write the response to
+//   - err: The error to report
+//
+// Example:
+//
+//	user, err := store.User(ctx, id)
+//	if err != nil {
+//	    netio.WriteErr(w, err)
+//	    return
+//	}
+//
+// The JSON response for ErrUserNotFound.Wrap(sql.ErrNoRows):
+//
+//	{
+//	    "error": {
+//	        "status": 404,
+//	        "message": "user does not exist",
+//	        "code": "user_not_found",
+//	        "timestamp": "2024-01-09T12:00:00Z"
+//	    }
+//	}
+func WriteErr(w http.ResponseWriter, err error) {
+	writeErr(w, nil, err)
+}
+
+// WriteErrRequest writes an error response for err like WriteErr. r is the
+// request being answered: its request ID (see RequestID) is added to the
+// response as "request_id" and it is passed to the error logger.
+//
+// Example:
+//
+//	user, err := store.User(r.Context(), id)
+//	if err != nil {
+//	    netio.WriteErrRequest(w, r, err)
+//	    return
+//	}
+func WriteErrRequest(w http.ResponseWriter, r *http.Request, err error) {
+	writeErr(w, r, err)
+}
+
+// writeErr implements WriteErr. r, if not nil, is passed on for logging.
+func writeErr(w http.ResponseWriter, r *http.Request, err error) {
+	if err == nil {
+		return
+	}
+	status, opts := errorOptions(r, err)
+	ErrorWith(w, status, opts)
+}
+
+// errorOptions returns the status and options of the error response for
+// err, keeping internal details out of everything sent to the client.
+func errorOptions(r *http.Request, err error) (int, ErrorOptions) {
+	opts := ErrorOptions{Request: r, Cause: err}
+
+	var httpErr HTTPError
+	if !errors.As(err, &httpErr) {
+		return http.StatusInternalServerError, opts
+	}
+
+	status := httpErr.StatusCode()
+	if status < 100 || status >= http.StatusInternalServerError {
+		return status, opts
+	}
+
+	var carrier validationCarrier
+	if errors.As(err, &carrier) {
+		opts.Validator = carrier.validator()
+	}
+	var coder interface{ ErrorCode() string }
+	if errors.As(err, &coder) {
+		opts.Code = coder.ErrorCode()
+	}
+	var messenger interface{ clientMessage() string }
+	if errors.As(err, &messenger) {
+		opts.Message = messenger.clientMessage()
+	}
+	return status, opts
+}
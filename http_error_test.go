@@ -0,0 +1,113 @@
+package netio
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStatusError(t *testing.T) {
+	errNotFound := NewHTTPError(http.StatusNotFound, "user_not_found", "user does not exist")
+	err := fmt.Errorf("loading user: %w", errNotFound.Wrap(sql.ErrNoRows))
+
+	if !errors.Is(err, errNotFound) {
+		t.Error("errors.Is() did not match the wrapped sentinel")
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Error("errors.Is() did not match the cause")
+	}
+	if errors.Is(err, NewHTTPError(http.StatusNotFound, "order_not_found", "")) {
+		t.Error("errors.Is() matched a StatusError with a different code")
+	}
+	if errNotFound.Cause != nil {
+		t.Error("Wrap() modified the sentinel")
+	}
+
+	// sentinels without a code are only matched by identity
+	errUserGone := NewHTTPError(http.StatusGone, "", "user was deleted")
+	errOrderGone := NewHTTPError(http.StatusGone, "", "order was deleted")
+	wrapped := fmt.Errorf("loading user: %w", errUserGone.Wrap(sql.ErrNoRows))
+	if !errors.Is(wrapped, errUserGone) {
+		t.Error("errors.Is() did not match the wrapped sentinel without a code")
+	}
+	if errors.Is(wrapped, errOrderGone) {
+		t.Error("errors.Is() matched a different sentinel without a code")
+	}
+
+	var httpErr HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode() != http.StatusNotFound {
+		t.Error("errors.As() did not find HTTPError with status 404")
+	}
+}
+
+func TestWriteErr(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantStatus  int
+		wantMessage string
+		wantCode    string
+	}{
+		{
+			name:        "status error",
+			err:         fmt.Errorf("handler: %w", NewHTTPError(http.StatusNotFound, "user_not_found", "user does not exist").Wrap(sql.ErrNoRows)),
+			wantStatus:  http.StatusNotFound,
+			wantMessage: "user does not exist",
+			wantCode:    "user_not_found",
+		},
+		{
+			name:        "server status error hides message",
+			err:         NewHTTPError(http.StatusServiceUnavailable, "db_down", "replica 3 unreachable"),
+			wantStatus:  http.StatusServiceUnavailable,
+			wantMessage: "Service Unavailable",
+		},
+		{
+			name:        "plain error",
+			err:         errors.New("secret internal detail"),
+			wantStatus:  http.StatusInternalServerError,
+			wantMessage: "Internal Server Error",
+		},
+		{
+			name:        "validation error",
+			err:         &ValidationError{Validator: NewValidator()},
+			wantStatus:  http.StatusUnprocessableEntity,
+			wantMessage: "Unprocessable Entity",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			WriteErr(w, test.err)
+
+			if w.Code != test.wantStatus {
+				t.Errorf("WriteErr() code = %v, want %v", w.Code, test.wantStatus)
+			}
+
+			var got struct {
+				Error ErrorResponse `json:"error"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatalf("WriteErr() invalid JSON response: %v", err)
+			}
+			if got.Error.Message != test.wantMessage {
+				t.Errorf("WriteErr() message = %q, want %q", got.Error.Message, test.wantMessage)
+			}
+			if got.Error.Code != test.wantCode {
+				t.Errorf("WriteErr() code = %q, want %q", got.Error.Code, test.wantCode)
+			}
+		})
+	}
+
+	t.Run("nil error", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		WriteErr(w, nil)
+		if w.Body.Len() != 0 {
+			t.Error("WriteErr() wrote a response for a nil error")
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package netio
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+)
+
+// errorLogger receives a record for every error response, see SetErrorLogger.
+// It is nil (logging disabled) by default.
+var errorLogger *slog.Logger
+
+// SetErrorLogger makes netio.Error, ErrorWith and WriteErr log every error
+// response they write through h. Passing nil disables logging again.
+//
+// Records are logged at slog.LevelError for 5xx responses, slog.LevelWarn
+// for 4xx responses and slog.LevelInfo otherwise, with the message
+// "error response" and these attributes when available:
+//   - status: the HTTP status code
+//   - key: the envelope key
+//   - method, path: from ErrorOptions.Request
+//   - request_id: the request ID of ErrorOptions.Request
+//   - cause: ErrorOptions.Cause, or the error given to WriteErr
+//   - write_error: the error that occurred while writing the response
+//
+// Example:
+//
+//	netio.SetErrorLogger(slog.NewJSONHandler(os.Stderr, nil))
+func SetErrorLogger(h slog.Handler) {
+	if h == nil {
+		errorLogger = nil
+		return
+	}
+	errorLogger = slog.New(h)
+}
+
+// logError logs an error response written by ErrorWith.
+func logError(status int, opts ErrorOptions, writeErr error) {
+	logger := errorLogger
+	if logger == nil {
+		return
+	}
+
+	level := slog.LevelInfo
+	switch {
+	case status >= 500:
+		level = slog.LevelError
+	case status >= 400:
+		level = slog.LevelWarn
+	}
+
+	ctx := context.Background()
+	attrs := []slog.Attr{
+		slog.Int("status", status),
+		slog.String("key", opts.Key),
+	}
+	if r := opts.Request; r != nil {
+		ctx = r.Context()
+		attrs = append(attrs,
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+		if id := requestID(r); id != "" {
+			attrs = append(attrs, slog.String("request_id", id))
+		}
+	}
+	if opts.Cause != nil {
+		attrs = append(attrs, slog.Any("cause", opts.Cause))
+	}
+	if writeErr != nil {
+		attrs = append(attrs, slog.Any("write_error", writeErr))
+	}
+
+	logger.LogAttrs(ctx, level, "error response", attrs...)
+}
+
+// requestID returns the ID of r stored by the RequestID middleware, falling
+// back to its X-Request-ID header when the middleware is not used.
+func requestID(r *http.Request) string {
+	if id := RequestIDFromContext(r.Context()); id != "" {
+		return id
+	}
+	if id := r.Header.Get(DefaultRequestIDHeader); validRequestID(id) {
+		return id
+	}
+	return ""
+}
@@ -0,0 +1,74 @@
+package netio
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSetErrorLogger(t *testing.T) {
+	var buf bytes.Buffer
+	SetErrorLogger(slog.NewJSONHandler(&buf, nil))
+	defer SetErrorLogger(nil)
+
+	tests := []struct {
+		name      string
+		status    int
+		cause     error
+		wantLevel string
+	}{
+		{"server error", http.StatusInternalServerError, errors.New("db down"), "ERROR"},
+		{"client error", http.StatusNotFound, nil, "WARN"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			buf.Reset()
+
+			r := httptest.NewRequest(http.MethodPost, "/orders/42", nil)
+			r.Header.Set("X-Request-ID", "req-123")
+			ErrorWith(httptest.NewRecorder(), tc.status, ErrorOptions{Request: r, Cause: tc.cause})
+
+			var record map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+				t.Fatalf("ErrorWith() did not log a JSON record: %v", err)
+			}
+
+			want := map[string]any{
+				"level":      tc.wantLevel,
+				"status":     float64(tc.status),
+				"key":        "error",
+				"method":     http.MethodPost,
+				"path":       "/orders/42",
+				"request_id": "req-123",
+			}
+			for key, value := range want {
+				if record[key] != value {
+					t.Errorf("ErrorWith() logged %s = %v, want %v", key, record[key], value)
+				}
+			}
+			if tc.cause != nil && record["cause"] != tc.cause.Error() {
+				t.Errorf("ErrorWith() logged cause = %v, want %v", record["cause"], tc.cause)
+			}
+		})
+	}
+
+	t.Run("write error", func(t *testing.T) {
+		buf.Reset()
+
+		w := &shortWriter{ResponseRecorder: httptest.NewRecorder(), limit: 1}
+		Error(w, "error", http.StatusBadRequest, nil)
+
+		var record map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatalf("Error() did not log a JSON record: %v", err)
+		}
+		if record["write_error"] == nil {
+			t.Error("Error() did not log the write failure")
+		}
+	})
+}
@@ -0,0 +1,176 @@
+package netio
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+)
+
+// ProblemContentType is the media type of RFC 9457 problem details responses.
+const ProblemContentType = "application/problem+json"
+
+// ProblemDetails represents an RFC 9457 "Problem Details for HTTP APIs"
+// object. The standard members are encoded alongside any Extensions as a
+// single flat JSON object.
+//
+// See https://www.rfc-editor.org/rfc/rfc9457 for the meaning of each member.
+type ProblemDetails struct {
+	// Type is a URI reference identifying the problem type ("about:blank" when unset)
+	Type string
+	// Title is a short, human-readable summary of the problem type
+	Title string
+	// Status is the HTTP status code
+	Status int
+	// Detail is a human-readable explanation specific to this occurrence
+	Detail string
+	// Instance is a URI reference identifying this occurrence of the problem
+	Instance string
+	// Extensions holds additional members. Keys that clash with the
+	// standard members above are ignored.
+	Extensions map[string]any
+}
+
+// ProblemFieldError is a single entry of the "errors" extension member
+// produced from a Validator.
+type ProblemFieldError struct {
+	// Field is the validator key the error belongs to
+	Field string `json:"field"`
+	// Detail is the validation message
+	Detail string `json:"detail"`
+}
+
+// MarshalJSON encodes the problem details as a single JSON object with the
+// extension members inlined next to the standard members.
+func (p ProblemDetails) MarshalJSON() ([]byte, error) {
+	obj := make(map[string]any, len(p.Extensions)+5)
+	for key, value := range p.Extensions {
+		obj[key] = value
+	}
+	for _, key := range []string{"type", "title", "status", "detail", "instance"} {
+		delete(obj, key)
+	}
+
+	obj["type"] = p.Type
+	if p.Type == "" {
+		obj["type"] = "about:blank"
+	}
+	obj["status"] = p.Status
+	if p.Title != "" {
+		obj["title"] = p.Title
+	}
+	if p.Detail != "" {
+		obj["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		obj["instance"] = p.Instance
+	}
+
+	return json.Marshal(obj)
+}
+
+// BuildProblem creates a new ProblemDetails with the specified HTTP status code.
+// The title is automatically set to the standard HTTP status text for the given code.
+func BuildProblem(status int) ProblemDetails {
+	return ProblemDetails{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+	}
+}
+
+// BuildProblemWithValidation creates a new ProblemDetails that includes
+// validation errors. Each validator error becomes an entry of the "errors"
+// extension member, sorted by field. When the Validator was created with
+// WithAllErrors every message of a field gets its own entry.
+func BuildProblemWithValidation(status int, v *Validator) ProblemDetails {
+	p := BuildProblem(status)
+
+	keys := make([]string, 0, len(v.Errors))
+	for key := range v.Errors {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	fieldErrors := make([]ProblemFieldError, 0, len(keys))
+	for _, key := range keys {
+		if !v.allErrors {
+			fieldErrors = append(fieldErrors, ProblemFieldError{Field: key, Detail: v.Errors[key]})
+			continue
+		}
+		for _, msg := range v.FieldErrors[key] {
+			fieldErrors = append(fieldErrors, ProblemFieldError{Field: key, Detail: msg})
+		}
+	}
+
+	p.Extensions = map[string]any{"errors": fieldErrors}
+	return p
+}
+
+// Problem writes an RFC 9457 problem details response with the
+// application/problem+json content type.
+//
+// The status code of the response is taken from p.Status and is corrected
+// to 500 if invalid. If the problem cannot be encoded (e.g. an extension
+// member is not JSON compatible), it falls back to a generic 500 problem.
+//
+// Example:
+//
+//	p := netio.BuildProblem(http.StatusNotFound)
+//	p.Detail = "order 42 does not exist"
+//	p.Instance = r.URL.Path
+//	netio.Problem(w, p)
+//
+// The JSON response:
+//
+//	{
+//	    "type": "about:blank",
+//	    "title": "Not Found",
+//	    "status": 404,
+//	    "detail": "order 42 does not exist",
+//	    "instance": "/orders/42"
+//	}
+//
+// Example with validation:
+//
+//	if !v.Valid() {
+//	    netio.Problem(w, netio.BuildProblemWithValidation(http.StatusUnprocessableEntity, v))
+//	    return
+//	}
+//
+// The JSON response format for validation errors:
+//
+//	{
+//	    "type": "about:blank",
+//	    "title": "Unprocessable Entity",
+//	    "status": 422,
+//	    "errors": [
+//	        {"field": "email", "detail": "must be a valid email"}
+//	    ]
+//	}
+func Problem(w http.ResponseWriter, p ProblemDetails) {
+	writeProblem(w, p)
+}
+
+// writeProblem implements Problem and reports write failures.
+func writeProblem(w http.ResponseWriter, p ProblemDetails) error {
+	if p.Status < 100 || p.Status > 599 {
+		p.Status = http.StatusInternalServerError
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	enc := newJSONEncoder(buf, writeConfig{mode: outputMode}.pretty())
+	err := enc.Encode(p)
+	if err != nil {
+		// if failed to marshal, fallback to writing generic problem
+		p = BuildProblem(http.StatusInternalServerError)
+		buf.Reset()
+		enc.Encode(p)
+	}
+
+	if writeErr := writeRaw(w, p.Status, ProblemContentType, buf.Bytes(), nil); writeErr != nil {
+		return writeErr
+	}
+	return err
+}
@@ -0,0 +1,130 @@
+package netio
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProblemDetails_MarshalJSON(t *testing.T) {
+	p := BuildProblem(http.StatusNotFound)
+	p.Detail = "order 42 does not exist"
+	p.Extensions = map[string]any{
+		"order_id": 42,
+		"status":   "ignored",
+	}
+
+	b, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("MarshalJSON() error = %v", err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("MarshalJSON() invalid JSON: %v", err)
+	}
+
+	want := map[string]any{
+		"type":     "about:blank",
+		"title":    "Not Found",
+		"status":   float64(404),
+		"detail":   "order 42 does not exist",
+		"order_id": float64(42),
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("MarshalJSON() %s = %v, want %v", key, got[key], value)
+		}
+	}
+	if _, ok := got["instance"]; ok {
+		t.Error("MarshalJSON() included empty instance member")
+	}
+}
+
+func TestProblem(t *testing.T) {
+	v := NewValidator()
+	v.AddError("name", "must be provided")
+	v.AddError("age", "must be over 18")
+
+	w := httptest.NewRecorder()
+	Problem(w, BuildProblemWithValidation(http.StatusUnprocessableEntity, v))
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Problem() code = %v, want %v", w.Code, http.StatusUnprocessableEntity)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != ProblemContentType {
+		t.Errorf("Problem() Content-Type = %q, want %q", ct, ProblemContentType)
+	}
+
+	var got struct {
+		Status int                 `json:"status"`
+		Errors []ProblemFieldError `json:"errors"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("Problem() invalid JSON response: %v", err)
+	}
+	if len(got.Errors) != 2 || got.Errors[0].Field != "age" || got.Errors[1].Field != "name" {
+		t.Errorf("Problem() errors = %v, want age and name sorted", got.Errors)
+	}
+}
+
+func TestError_ProblemFormat(t *testing.T) {
+	SetErrorFormat(ErrorFormatProblem)
+	defer SetErrorFormat(ErrorFormatEnvelope)
+
+	w := httptest.NewRecorder()
+	Error(w, "error", http.StatusNotFound, nil)
+
+	if ct := w.Header().Get("Content-Type"); ct != ProblemContentType {
+		t.Errorf("Error() Content-Type = %q, want %q", ct, ProblemContentType)
+	}
+
+	var got map[string]any
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("Error() invalid JSON response: %v", err)
+	}
+	if got["title"] != "Not Found" {
+		t.Errorf("Error() title = %v, want Not Found", got["title"])
+	}
+	if _, ok := got["error"]; ok {
+		t.Error("Error() wrote legacy envelope in problem mode")
+	}
+}
+
+func TestErrorWith_ProblemMetaCollision(t *testing.T) {
+	SetErrorFormat(ErrorFormatProblem)
+	defer SetErrorFormat(ErrorFormatEnvelope)
+
+	v := NewValidator()
+	v.AddError("email", "must be provided")
+
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set("X-Request-ID", "req-123")
+	w := httptest.NewRecorder()
+	ErrorWith(w, http.StatusUnprocessableEntity, ErrorOptions{
+		Validator: v,
+		Code:      "invalid_input",
+		Request:   r,
+		Meta:      map[string]any{"errors": "clobbered", "code": "x", "request_id": "y", "retry_after": 30},
+	})
+
+	var got struct {
+		Errors     []ProblemFieldError `json:"errors"`
+		Code       string              `json:"code"`
+		RequestID  string              `json:"request_id"`
+		RetryAfter int                 `json:"retry_after"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("ErrorWith() invalid JSON response: %v", err)
+	}
+	if len(got.Errors) != 1 || got.Errors[0].Field != "email" {
+		t.Errorf("ErrorWith() errors = %v, want the validation errors", got.Errors)
+	}
+	if got.Code != "invalid_input" || got.RequestID != "req-123" {
+		t.Errorf("ErrorWith() code = %q, request_id = %q, want Meta not to replace them", got.Code, got.RequestID)
+	}
+	if got.RetryAfter != 30 {
+		t.Errorf("ErrorWith() retry_after = %v, want 30", got.RetryAfter)
+	}
+}
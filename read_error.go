@@ -0,0 +1,215 @@
+package netio
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ReadErrorKind classifies why a request body could not be read.
+type ReadErrorKind int
+
+const (
+	// KindSyntax means the body contains malformed JSON.
+	KindSyntax ReadErrorKind = iota + 1
+	// KindUnexpectedEOF means the body ended in the middle of a JSON value.
+	KindUnexpectedEOF
+	// KindTypeMismatch means a JSON value has the wrong type for its destination.
+	KindTypeMismatch
+	// KindUnknownField means the body contains a field that does not exist on the destination.
+	KindUnknownField
+	// KindTooLarge means the body exceeded the Reader's maximum size.
+	KindTooLarge
+	// KindEmpty means the body was empty.
+	KindEmpty
+	// KindMultipleValues means the body contains more than one JSON value.
+	KindMultipleValues
+	// KindContentType means the request Content-Type was not accepted by the Reader.
+	KindContentType
+	// KindMalformedForm means a form body could not be parsed.
+	KindMalformedForm
+)
+
+// String returns a short lowercase name for the kind, e.g. "syntax".
+func (k ReadErrorKind) String() string {
+	switch k {
+	case KindSyntax:
+		return "syntax"
+	case KindUnexpectedEOF:
+		return "unexpected_eof"
+	case KindTypeMismatch:
+		return "type_mismatch"
+	case KindUnknownField:
+		return "unknown_field"
+	case KindTooLarge:
+		return "too_large"
+	case KindEmpty:
+		return "empty"
+	case KindMultipleValues:
+		return "multiple_values"
+	case KindContentType:
+		return "content_type"
+	case KindMalformedForm:
+		return "malformed_form"
+	default:
+		return "unknown"
+	}
+}
+
+// ReadError is returned by Read, ReadForm and ReadStream when the request
+// body cannot be decoded.
+// It classifies the failure so handlers can react without inspecting
+// error strings, and it provides a message that is safe to send to clients.
+//
+// The underlying decoder error is available through errors.Unwrap, so
+// errors.Is and errors.As keep working against json and net/http errors
+// as well as netio sentinels such as ErrEmptyBody.
+//
+// Example:
+//
+//	var rerr *netio.ReadError
+//	if errors.As(err, &rerr) && rerr.Kind == netio.KindUnknownField {
+//	    log.Printf("client sent unknown field %q", rerr.Field)
+//	}
+type ReadError struct {
+	// Kind classifies the failure.
+	Kind ReadErrorKind
+	// Field is the offending JSON field when known (e.g. "address.postcode").
+	Field string
+	// Offset is the byte offset in the body where the error occurred, when known.
+	Offset int64
+	// Line is the 1-based line of the record that failed when reading a
+	// stream with ReadStream, 0 otherwise.
+	Line int
+	// Err is the underlying error.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *ReadError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("netio.ReadStream(): line %d: %s", e.Line, e.Err.Error())
+	}
+	return "netio.Read(): " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *ReadError) Unwrap() error {
+	return e.Err
+}
+
+// Message returns a description of the error that is safe to show to API
+// clients. It never includes Go type names or other internal details.
+func (e *ReadError) Message() string {
+	switch e.Kind {
+	case KindSyntax:
+		return fmt.Sprintf("body contains badly-formed JSON (at character %d)", e.Offset)
+	case KindUnexpectedEOF:
+		return "body contains badly-formed JSON"
+	case KindTypeMismatch:
+		if e.Field != "" {
+			return fmt.Sprintf("body contains incorrect JSON type for field %q", e.Field)
+		}
+		return fmt.Sprintf("body contains incorrect JSON type (at character %d)", e.Offset)
+	case KindUnknownField:
+		return fmt.Sprintf("body contains unknown field %q", e.Field)
+	case KindTooLarge:
+		var maxErr *http.MaxBytesError
+		if errors.As(e.Err, &maxErr) {
+			return fmt.Sprintf("body must not be larger than %d bytes", maxErr.Limit)
+		}
+		if errors.Is(e.Err, ErrRecordTooLarge) {
+			return e.Err.Error()
+		}
+		return "body is too large"
+	case KindEmpty:
+		return "body must not be empty"
+	case KindMultipleValues:
+		return "body must only contain a single JSON value"
+	case KindContentType:
+		return e.Err.Error()
+	case KindMalformedForm:
+		return "body contains a badly-formed form"
+	default:
+		return "body could not be read"
+	}
+}
+
+// classifyDecodeError converts an error returned by json.Decoder.Decode into
+// a *ReadError. Errors that are caused by the caller rather than the client
+// (e.g. a non-pointer dst) are wrapped but not classified.
+func classifyDecodeError(err error, dec *json.Decoder) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var maxErr *http.MaxBytesError
+
+	switch {
+	case errors.As(err, &syntaxErr):
+		return &ReadError{Kind: KindSyntax, Offset: syntaxErr.Offset, Err: err}
+
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return &ReadError{Kind: KindUnexpectedEOF, Offset: dec.InputOffset(), Err: err}
+
+	case errors.As(err, &typeErr):
+		return &ReadError{Kind: KindTypeMismatch, Field: typeErr.Field, Offset: typeErr.Offset, Err: err}
+
+	// encoding/json does not export a type for unknown fields, so the
+	// field name has to be taken from the message
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.TrimPrefix(err.Error(), "json: unknown field ")
+		return &ReadError{Kind: KindUnknownField, Field: strings.Trim(field, `"`), Offset: dec.InputOffset(), Err: err}
+
+	case errors.As(err, &maxErr):
+		return &ReadError{Kind: KindTooLarge, Offset: maxErr.Limit, Err: err}
+
+	case errors.Is(err, io.EOF):
+		return &ReadError{Kind: KindEmpty, Err: ErrEmptyBody}
+
+	default:
+		return fmt.Errorf("netio.Read(): %w", err)
+	}
+}
+
+// StatusCode returns the HTTP status code that best describes the error:
+//   - 413 Request Entity Too Large for KindTooLarge
+//   - 415 Unsupported Media Type for KindContentType
+//   - 422 Unprocessable Entity for KindTypeMismatch
+//   - 400 Bad Request for everything else
+func (e *ReadError) StatusCode() int {
+	switch e.Kind {
+	case KindTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case KindContentType:
+		return http.StatusUnsupportedMediaType
+	case KindTypeMismatch:
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// validator returns a Validator holding the client message keyed by the
+// offending field, or by "body" when the error is not tied to a field.
+func (e *ReadError) validator() *Validator {
+	v := NewValidator()
+	e.addTo(v)
+	return v
+}
+
+// addTo records the client message in v. Stream errors are keyed by their
+// line, e.g. "line[3].name" or "line[3]" when not tied to a field.
+func (e *ReadError) addTo(v *Validator) {
+	if e.Line > 0 {
+		v.Index("line", e.Line).AddError(e.Field, e.Message())
+		return
+	}
+
+	key := e.Field
+	if key == "" {
+		key = "body"
+	}
+	v.AddError(key, e.Message())
+}
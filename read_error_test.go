@@ -0,0 +1,70 @@
+package netio
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRead_ErrorKinds(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []ReadOption
+		body      string
+		wantKind  ReadErrorKind
+		wantField string
+	}{
+		{"syntax error", nil, `{"name": "test",}`, KindSyntax, ""},
+		{"unexpected eof", nil, `{"name": "test"`, KindUnexpectedEOF, ""},
+		{"type mismatch", nil, `{"age": "thirty"}`, KindTypeMismatch, "age"},
+		{"nested type mismatch", nil, `{"address": {"postcode": 3000}}`, KindTypeMismatch, "address.postcode"},
+		{"unknown field", nil, `{"nickname": "tester"}`, KindUnknownField, "nickname"},
+		{"too large", []ReadOption{WithMaxBytes(10)}, `{"name": "far too long for the limit"}`, KindTooLarge, ""},
+		{"empty body", nil, ``, KindEmpty, ""},
+		{"multiple values", nil, `{"name": "a"}{"name": "b"}`, KindMultipleValues, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.body))
+			w := httptest.NewRecorder()
+
+			var dst struct {
+				Name    string `json:"name"`
+				Age     int    `json:"age"`
+				Address struct {
+					Postcode string `json:"postcode"`
+				} `json:"address"`
+			}
+
+			err := NewReader(test.opts...).Read(w, r, &dst)
+
+			var rerr *ReadError
+			if !errors.As(err, &rerr) {
+				t.Fatalf("Read() error = %v, want *ReadError", err)
+			}
+			if rerr.Kind != test.wantKind {
+				t.Errorf("Read() kind = %v, want %v", rerr.Kind, test.wantKind)
+			}
+			if rerr.Field != test.wantField {
+				t.Errorf("Read() field = %q, want %q", rerr.Field, test.wantField)
+			}
+			if rerr.Message() == "" {
+				t.Error("Read() returned empty client message")
+			}
+		})
+	}
+}
+
+func TestReadError_Unwrap(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}{}`))
+	w := httptest.NewRecorder()
+
+	var dst struct{}
+	err := Read(w, r, &dst)
+	if !errors.Is(err, ErrMultipleJsonBodies) {
+		t.Errorf("Read() error = %v, want errors.Is ErrMultipleJsonBodies", err)
+	}
+}
@@ -0,0 +1,203 @@
+package netio
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+)
+
+// ErrRecordTooLarge is returned by ReadStream when a single line exceeds
+// ReadStreamOptions.MaxRecordBytes.
+var ErrRecordTooLarge = errors.New("record is too large")
+
+// ReadStreamOptions configures ReadStream. All fields are optional.
+type ReadStreamOptions struct {
+	// Reader supplies the total body size limit, unknown field policy,
+	// number decoding and required Content-Type (defaults to the Reader
+	// used by netio.Read)
+	Reader *Reader
+	// MaxRecordBytes limits the size of a single line, not counting the
+	// line break (defaults to DefaultMaxBytes, capped at the total limit)
+	MaxRecordBytes int
+	// ContinueOnError keeps reading after a line that cannot be decoded.
+	// Errors that affect the whole body, such as exceeding the total size
+	// limit, always stop the stream.
+	ContinueOnError bool
+	// Validator, when ContinueOnError is set, collects the messages of bad
+	// lines keyed by line number (e.g. "line[3].name") instead of them
+	// being yielded
+	Validator *Validator
+}
+
+// ReadStream decodes a newline delimited JSON (NDJSON / JSON Lines) request
+// body one record at a time, so bulk uploads never have to be held in
+// memory. Blank lines are skipped and lines may end in "\r\n".
+//
+// Each record is decoded into a T and yielded with a nil error. A line that
+// cannot be decoded is yielded as a *ReadError with its Line and body
+// Offset set, after which the sequence ends unless
+// ReadStreamOptions.ContinueOnError is set. The sequence always ends after
+// an error that affects the whole body, e.g. a *ReadError of KindTooLarge
+// without a Line once the total size limit is exceeded.
+//
+// The request body can only be read once, so the returned sequence is
+// single use.
+//
+// Parameters:
+//   - w: The http.ResponseWriter (used for MaxBytesReader)
+//   - r: The *http.Request containing the NDJSON body
+//   - opts: Size limits and error handling
+//
+// Example:
+//
+//	v := netio.NewValidator()
+//	opts := netio.ReadStreamOptions{
+//	    Reader:          netio.NewReader(netio.WithMaxBytes(100 << 20)),
+//	    MaxRecordBytes:  16 << 10,
+//	    ContinueOnError: true,
+//	    Validator:       v,
+//	}
+//	for order, err := range netio.ReadStream[Order](w, r, opts) {
+//	    if err != nil {
+//	        netio.WriteErr(w, err)
+//	        return
+//	    }
+//	    store.Import(order)
+//	}
+//	if !v.Valid() {
+//	    netio.Error(w, "error", http.StatusUnprocessableEntity, v)
+//	    return
+//	}
+func ReadStream[T any](w http.ResponseWriter, r *http.Request, opts ReadStreamOptions) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+
+		rd := opts.Reader
+		if rd == nil {
+			rd = defaultReader
+		}
+		if err := rd.checkContentType(r); err != nil {
+			yield(zero, err)
+			return
+		}
+
+		maxRecord := opts.MaxRecordBytes
+		if maxRecord <= 0 || int64(maxRecord) > rd.maxBytes {
+			maxRecord = int(min(DefaultMaxBytes, rd.maxBytes))
+		}
+
+		// set maximum bytes to receive to prevent/mitigate DOS on API
+		r.Body = http.MaxBytesReader(w, r.Body, rd.maxBytes)
+		br := bufio.NewReader(r.Body)
+		// buf holds the current record; it grows with the largest record
+		// read so far, up to maxRecord plus its line break
+		var buf []byte
+
+		// fail reports a bad line and returns whether to keep reading
+		fail := func(err *ReadError) bool {
+			if !opts.ContinueOnError {
+				yield(zero, err)
+				return false
+			}
+			if opts.Validator != nil {
+				err.addTo(opts.Validator)
+				return true
+			}
+			return yield(zero, err)
+		}
+
+		var offset int64
+		for line := 1; ; line++ {
+			start := offset
+			b, n, tooLarge, err := readRecord(br, buf[:0], maxRecord+2)
+			buf = b
+			offset += n
+			tooLarge = tooLarge || len(bytes.TrimRight(b, "\r\n")) > maxRecord
+			if err != nil && !errors.Is(err, io.EOF) {
+				yield(zero, streamBodyError(err))
+				return
+			}
+
+			if tooLarge {
+				if !fail(&ReadError{
+					Kind:   KindTooLarge,
+					Line:   line,
+					Offset: start,
+					Err:    fmt.Errorf("%w: must not be larger than %d bytes", ErrRecordTooLarge, maxRecord),
+				}) {
+					return
+				}
+			} else if record := bytes.TrimSpace(b); len(record) > 0 {
+				var v T
+				if decErr := decodeRecord(rd, record, &v); decErr != nil {
+					var rerr *ReadError
+					if !errors.As(decErr, &rerr) {
+						yield(zero, decErr)
+						return
+					}
+					// decoder offsets are relative to the trimmed record
+					lead := len(b) - len(bytes.TrimLeft(b, " \t\r\n"))
+					rerr.Line = line
+					rerr.Offset += start + int64(lead)
+					if !fail(rerr) {
+						return
+					}
+				} else if !yield(v, nil) {
+					return
+				}
+			}
+
+			if err != nil {
+				// io.EOF
+				return
+			}
+		}
+	}
+}
+
+// readRecord reads the next line of br, including its line break, into
+// buf. Lines longer than max bytes are consumed but not kept, in which case
+// tooLarge is set. n is the number of bytes consumed.
+func readRecord(br *bufio.Reader, buf []byte, max int) (line []byte, n int64, tooLarge bool, err error) {
+	for {
+		chunk, err := br.ReadSlice('\n')
+		n += int64(len(chunk))
+		if !tooLarge {
+			if len(buf)+len(chunk) > max {
+				tooLarge, buf = true, buf[:0]
+			} else {
+				buf = append(buf, chunk...)
+			}
+		}
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			return buf, n, tooLarge, err
+		}
+	}
+}
+
+// decodeRecord decodes a single NDJSON record into dst.
+func decodeRecord(rd *Reader, record []byte, dst any) error {
+	dec := rd.newDecoder(bytes.NewReader(record))
+	if err := dec.Decode(dst); err != nil {
+		return classifyDecodeError(err, dec)
+	}
+	// a record holds exactly one JSON value, see Read
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		return &ReadError{Kind: KindMultipleValues, Offset: dec.InputOffset(), Err: ErrMultipleJsonBodies}
+	}
+	return nil
+}
+
+// streamBodyError converts an error reading the stream body into a
+// *ReadError where possible.
+func streamBodyError(err error) error {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return &ReadError{Kind: KindTooLarge, Offset: maxErr.Limit, Err: err}
+	}
+	return fmt.Errorf("netio.ReadStream(): %w", err)
+}
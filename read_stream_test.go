@@ -0,0 +1,155 @@
+package netio
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type streamRecord struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestReadStream(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		opts      ReadStreamOptions
+		wantIDs   []int
+		wantLines []int
+		wantKinds []ReadErrorKind
+	}{
+		{
+			name:    "records",
+			body:    "{\"id\":1}\n\n{\"id\":2}\r\n{\"id\":3}",
+			wantIDs: []int{1, 2, 3},
+		},
+		{
+			name:      "stops at bad line",
+			body:      "{\"id\":1}\n{\"id\":\n{\"id\":3}\n",
+			wantIDs:   []int{1},
+			wantLines: []int{2},
+			wantKinds: []ReadErrorKind{KindUnexpectedEOF},
+		},
+		{
+			name:      "continue on error",
+			body:      "{\"id\":1}\n{\"id\":\"x\"}\n{\"id\":3,\"extra\":true}\n{\"id\":4} {}\n{\"id\":5}\n",
+			opts:      ReadStreamOptions{ContinueOnError: true},
+			wantIDs:   []int{1, 5},
+			wantLines: []int{2, 3, 4},
+			wantKinds: []ReadErrorKind{KindTypeMismatch, KindUnknownField, KindMultipleValues},
+		},
+		{
+			name:      "record too large",
+			body:      "{\"id\":1}\n{\"id\":2,\"name\":\"" + strings.Repeat("a", 64) + "\"}\n{\"id\":3}\n",
+			opts:      ReadStreamOptions{MaxRecordBytes: 32, ContinueOnError: true},
+			wantIDs:   []int{1, 3},
+			wantLines: []int{2},
+			wantKinds: []ReadErrorKind{KindTooLarge},
+		},
+		{
+			name:    "record larger than read buffer",
+			body:    "{\"id\":1,\"name\":\"" + strings.Repeat("a", 10<<10) + "\"}\n{\"id\":2}\n",
+			wantIDs: []int{1, 2},
+		},
+		{
+			name:      "record too large across reads",
+			body:      "{\"id\":1,\"name\":\"" + strings.Repeat("a", 10<<10) + "\"}\n{\"id\":2}\n",
+			opts:      ReadStreamOptions{MaxRecordBytes: 6 << 10, ContinueOnError: true},
+			wantIDs:   []int{2},
+			wantLines: []int{1},
+			wantKinds: []ReadErrorKind{KindTooLarge},
+		},
+		{
+			name:      "total too large",
+			body:      strings.Repeat("{\"id\":1}\n", 10),
+			opts:      ReadStreamOptions{Reader: NewReader(WithMaxBytes(40)), ContinueOnError: true},
+			wantIDs:   []int{1, 1, 1, 1},
+			wantLines: []int{0},
+			wantKinds: []ReadErrorKind{KindTooLarge},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/import", strings.NewReader(tc.body))
+
+			var ids, lines []int
+			var kinds []ReadErrorKind
+			for rec, err := range ReadStream[streamRecord](w, r, tc.opts) {
+				if err != nil {
+					var rerr *ReadError
+					if !errors.As(err, &rerr) {
+						t.Fatalf("ReadStream() error = %v, want *ReadError", err)
+					}
+					lines = append(lines, rerr.Line)
+					kinds = append(kinds, rerr.Kind)
+					continue
+				}
+				ids = append(ids, rec.ID)
+			}
+
+			if !slices.Equal(ids, tc.wantIDs) {
+				t.Errorf("ReadStream() ids = %v, want %v", ids, tc.wantIDs)
+			}
+			if !slices.Equal(lines, tc.wantLines) {
+				t.Errorf("ReadStream() error lines = %v, want %v", lines, tc.wantLines)
+			}
+			if !slices.Equal(kinds, tc.wantKinds) {
+				t.Errorf("ReadStream() error kinds = %v, want %v", kinds, tc.wantKinds)
+			}
+		})
+	}
+}
+
+func TestReadStream_Validator(t *testing.T) {
+	body := "{\"id\":1}\n  {\"id\":\"x\"}\n{\"id\":3,\"nme\":\"a\"}\n"
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/import", strings.NewReader(body))
+
+	v := NewValidator()
+	var ids []int
+	for rec, err := range ReadStream[streamRecord](w, r, ReadStreamOptions{ContinueOnError: true, Validator: v}) {
+		if err != nil {
+			t.Fatalf("ReadStream() yielded error %v, want it collected", err)
+		}
+		ids = append(ids, rec.ID)
+	}
+
+	if !slices.Equal(ids, []int{1}) {
+		t.Errorf("ReadStream() ids = %v, want [1]", ids)
+	}
+	want := map[string]string{
+		"line[2].id":  `body contains incorrect JSON type for field "id"`,
+		"line[3].nme": `body contains unknown field "nme"`,
+	}
+	for key, msg := range want {
+		if v.Errors[key] != msg {
+			t.Errorf("Validator.Errors[%q] = %q, want %q", key, v.Errors[key], msg)
+		}
+	}
+}
+
+func TestReadStream_Offset(t *testing.T) {
+	body := "{\"id\":1}\n  {\"id\":}\n"
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/import", strings.NewReader(body))
+
+	for _, err := range ReadStream[streamRecord](w, r, ReadStreamOptions{}) {
+		var rerr *ReadError
+		if errors.As(err, &rerr) {
+			// the offending '}' is the 18th character of the body
+			if rerr.Offset != 18 || rerr.Kind != KindSyntax {
+				t.Errorf("ReadStream() error = %+v, want syntax error at offset 18", rerr)
+			}
+			if !strings.HasPrefix(rerr.Error(), "netio.ReadStream(): line 2: ") {
+				t.Errorf("ReadStream() error message = %q", rerr.Error())
+			}
+		}
+	}
+}
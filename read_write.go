@@ -1,14 +1,26 @@
 // Package netio provides lightweight utilities that simplify common
 // webserver development tasks in Go. This package aims to reduce boilerplate
 // code for simple components so the developer can focus on application logic.
+//
+// Package wide settings (SetErrorFormat, SetOutputMode and SetErrorLogger)
+// are meant to be configured once during program start up, before any
+// handlers run. SetClock and SetTimestampFormat are safe to change at any
+// time, and an ErrorBuilder gives tests their own clock and timestamp format.
 package netio
 
 import (
+	"bufio"
+	"bytes"
+	"encoding"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"io"
 	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 )
 
 var (
@@ -36,54 +48,382 @@ var (
 //	}
 type Envelope map[string]any
 
+// OutputMode controls how Write formats JSON.
+type OutputMode int
+
+const (
+	// OutputPretty indents JSON with tabs for readability. This is the default.
+	OutputPretty OutputMode = iota
+	// OutputCompact writes JSON without any insignificant whitespace.
+	OutputCompact
+	// OutputAuto writes compact JSON unless the request asks for pretty
+	// output with ?pretty=true or comes from a command line client such as
+	// curl. The request must be supplied with WithRequest, otherwise the
+	// output is compact.
+	OutputAuto
+)
+
+// outputMode is the package wide default used by Write, see SetOutputMode.
+var outputMode = OutputPretty
+
+// SetOutputMode changes the default JSON formatting used by Write for the
+// whole package. Individual calls can still override it with WithOutputMode.
+//
+// Example:
+//
+//	func main() {
+//	    // compact for apps, pretty when debugging with curl
+//	    netio.SetOutputMode(netio.OutputAuto)
+//	    ...
+//	}
+func SetOutputMode(m OutputMode) {
+	outputMode = m
+}
+
+// writeConfig holds the per-call settings of Write.
+type writeConfig struct {
+	mode      OutputMode
+	r         *http.Request
+	streaming bool
+}
+
+// WriteOption configures a single call to Write.
+type WriteOption func(*writeConfig)
+
+// WithOutputMode overrides the package default set by SetOutputMode for
+// a single call.
+func WithOutputMode(m OutputMode) WriteOption {
+	return func(c *writeConfig) {
+		c.mode = m
+	}
+}
+
+// WithRequest supplies the request being answered. It is used by
+// OutputAuto to look for ?pretty=true and the User-Agent header.
+func WithRequest(r *http.Request) WriteOption {
+	return func(c *writeConfig) {
+		c.r = r
+	}
+}
+
+// WithStreaming encodes the data straight to the ResponseWriter instead of
+// buffering the whole body first. Lists held by the envelope are encoded
+// one element at a time, which keeps memory flat for very large responses.
+// The trade-off is that headers and status are sent before encoding starts:
+// Content-Length is not set and an encoding failure can no longer be turned
+// into an error response.
+func WithStreaming() WriteOption {
+	return func(c *writeConfig) {
+		c.streaming = true
+	}
+}
+
+// pretty reports whether the output should be indented.
+func (c writeConfig) pretty() bool {
+	switch c.mode {
+	case OutputCompact:
+		return false
+	case OutputAuto:
+		return c.r != nil && wantsPretty(c.r)
+	default:
+		return true
+	}
+}
+
+// wantsPretty reports whether r asks for indented output, either explicitly
+// with ?pretty=true or implicitly by coming from a command line client.
+func wantsPretty(r *http.Request) bool {
+	if pretty, err := strconv.ParseBool(r.URL.Query().Get("pretty")); err == nil {
+		return pretty
+	}
+	ua := strings.ToLower(r.UserAgent())
+	for _, client := range []string{"curl/", "wget/", "httpie/"} {
+		if strings.HasPrefix(ua, client) {
+			return true
+		}
+	}
+	return false
+}
+
+// newJSONEncoder returns an encoder writing to w, indented with tabs when
+// pretty is true. Both forms end with a newline for terminal i.e. curl responses.
+func newJSONEncoder(w io.Writer, pretty bool) *json.Encoder {
+	enc := json.NewEncoder(w)
+	if pretty {
+		enc.SetIndent("", "\t")
+	}
+	return enc
+}
+
+// streamEnvelope encodes data to w one value at a time. Lists held by the
+// envelope are encoded element by element, so only a single element is
+// held in memory at once. The output is identical to encoding data with
+// newJSONEncoder.
+func streamEnvelope(w io.Writer, data Envelope, pretty bool) error {
+	bw := bufio.NewWriter(w)
+
+	marshal := func(v any, prefix string) ([]byte, error) {
+		if pretty {
+			return json.MarshalIndent(v, prefix, "\t")
+		}
+		return json.Marshal(v)
+	}
+	// newline writes a line break followed by depth levels of indentation
+	newline := func(depth int) {
+		if pretty {
+			bw.WriteByte('\n')
+			bw.WriteString(strings.Repeat("\t", depth))
+		}
+	}
+
+	if data == nil {
+		bw.WriteString("null\n")
+		return bw.Flush()
+	}
+
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	bw.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			bw.WriteByte(',')
+		}
+		newline(1)
+
+		k, _ := json.Marshal(key)
+		bw.Write(k)
+		bw.WriteByte(':')
+		if pretty {
+			bw.WriteByte(' ')
+		}
+
+		value := reflect.ValueOf(data[key])
+		if !isStreamableList(value) || value.Len() == 0 {
+			b, err := marshal(data[key], "\t")
+			if err != nil {
+				return ErrNetioMarshalFailure
+			}
+			bw.Write(b)
+			continue
+		}
+
+		bw.WriteByte('[')
+		for j := 0; j < value.Len(); j++ {
+			if j > 0 {
+				bw.WriteByte(',')
+			}
+			newline(2)
+			b, err := marshal(value.Index(j).Interface(), "\t\t")
+			if err != nil {
+				return ErrNetioMarshalFailure
+			}
+			if _, err := bw.Write(b); err != nil {
+				return err
+			}
+		}
+		newline(1)
+		bw.WriteByte(']')
+	}
+	if len(keys) > 0 {
+		newline(0)
+	}
+	bw.WriteString("}\n")
+
+	return bw.Flush()
+}
+
+var (
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// isStreamableList reports whether streamEnvelope may encode v element by
+// element. Byte slices and arrays, nil slices and types with their own JSON
+// or text encoding (e.g. uuid.UUID) are encoded as a whole, exactly like
+// encoding/json would.
+func isStreamableList(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.IsNil() {
+			return false
+		}
+	case reflect.Array:
+	default:
+		return false
+	}
+	t := v.Type()
+	if t.Elem().Kind() == reflect.Uint8 {
+		return false
+	}
+	for _, iface := range []reflect.Type{jsonMarshalerType, textMarshalerType} {
+		if t.Implements(iface) || reflect.PointerTo(t).Implements(iface) {
+			return false
+		}
+	}
+	return true
+}
+
 // Write sends a JSON response with the given status code and response data.
 // It handles JSON formatting, sets appropriate headers, and provides pretty-printing
 // for better CLI tool readability.
 //
+// The data is encoded into a pooled buffer before anything is sent, so an
+// encoding failure leaves the response untouched and the caller is still free
+// to write an error response. Caller headers are applied after the defaults
+// (and can therefore override them), Content-Length is set from the encoded
+// body and only then is the status code written.
+//
+// Formatting follows the package default (see SetOutputMode) unless
+// overridden with options.
+//
 // Parameters:
 //   - w: The http.ResponseWriter to write the response to
 //   - status: HTTP status code to send
 //   - data: The Envelope containing response data to be JSON encoded
 //   - headers: Additional HTTP headers to include in the response
+//   - opts: Optional per-call settings (WithOutputMode, WithRequest, WithStreaming)
+//
+// Statuses that do not allow a body (1xx, 204 and 304) are written with
+// headers only; data is ignored.
 //
-// Returns an error if JSON marshaling fails, nil otherwise.
+// Returns ErrNetioMarshalFailure if JSON encoding fails (nothing is written),
+// or the error from the underlying ResponseWriter if the body could not be
+// written in full (io.ErrShortWrite for short writes), nil otherwise.
 //
 // Example:
 //
 //	env := netio.Envelope{"users": users}
 //	headers := http.Header{"X-Custom": []string{"value"}}
 //	err := netio.Write(w, http.StatusOK, env, headers)
-func Write(w http.ResponseWriter, status int, data Envelope, headers http.Header) error {
-	// header good practices (OWASP)
-	// see more at https://cheatsheetseries.owasp.org/cheatsheets/HTTP_Headers_Cheat_Sheet.html
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("X-Content-Type-Options", "nosniff")
-	w.Header().Set("X-Frame-Options", "DENY")
+//
+//	// compact output unless the client asked for ?pretty=true or is curl
+//	err = netio.Write(w, http.StatusOK, env, nil,
+//	    netio.WithOutputMode(netio.OutputAuto),
+//	    netio.WithRequest(r),
+//	)
+func Write(w http.ResponseWriter, status int, data Envelope, headers http.Header, opts ...WriteOption) error {
+	cfg := writeConfig{mode: outputMode}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
 
-	w.WriteHeader(status)
+	if !bodyAllowedForStatus(status) {
+		return writeRaw(w, status, "application/json", nil, headers)
+	}
 
-	json, err := json.MarshalIndent(data, "", "\t")
-	if err != nil {
+	if cfg.streaming {
+		setDefaultHeaders(w.Header(), "application/json")
+		for key, values := range headers {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(status)
+
+		return streamEnvelope(w, data, cfg.pretty())
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	if err := newJSONEncoder(buf, cfg.pretty()).Encode(data); err != nil {
 		return ErrNetioMarshalFailure
 	}
 
-	// formatting for terminal i.e. curl responses
-	json = append(json, '\n')
+	return writeRaw(w, status, "application/json", buf.Bytes(), headers)
+}
+
+// maxPooledBuffer is the largest buffer returned to bufferPool. Larger
+// buffers are dropped so one huge response does not pin memory forever.
+const maxPooledBuffer = 64 << 10
+
+// bufferPool holds buffers used to encode response bodies.
+var bufferPool = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
+// getBuffer returns an empty buffer from bufferPool.
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// putBuffer returns buf to bufferPool.
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() <= maxPooledBuffer {
+		bufferPool.Put(buf)
+	}
+}
+
+// setDefaultHeaders sets the content type together with the security
+// headers every netio response carries.
+func setDefaultHeaders(h http.Header, contentType string) {
+	// header good practices (OWASP)
+	// see more at https://cheatsheetseries.owasp.org/cheatsheets/HTTP_Headers_Cheat_Sheet.html
+	h.Set("Content-Type", contentType)
+	h.Set("X-Content-Type-Options", "nosniff")
+	h.Set("X-Frame-Options", "DENY")
+}
+
+// writeRaw writes an already encoded body together with the default
+// security headers. Caller headers are applied before the status is
+// written so they can override the defaults. For statuses that do not
+// allow a body (1xx, 204 and 304) only the headers and status are written.
+func writeRaw(w http.ResponseWriter, status int, contentType string, body []byte, headers http.Header) error {
+	setDefaultHeaders(w.Header(), contentType)
 
 	// go through headers map and apply headers
 	for key, values := range headers {
 		w.Header()[key] = values
 	}
 
-	w.Write(json)
+	if !bodyAllowedForStatus(status) {
+		w.WriteHeader(status)
+		return nil
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+
+	w.WriteHeader(status)
+
+	n, err := w.Write(body)
+	if err != nil {
+		return err
+	}
+	if n < len(body) {
+		return io.ErrShortWrite
+	}
 
 	return nil
 }
 
+// bodyAllowedForStatus reports whether a response with status may have a
+// body, mirroring net/http which rejects writes for the others.
+func bodyAllowedForStatus(status int) bool {
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == http.StatusNoContent, status == http.StatusNotModified:
+		return false
+	}
+	return true
+}
+
 // Read decodes a JSON request body into the provided destination struct.
 // It enforces a maximum request size of 1MB and validates that only a single
 // JSON object is present in the request body.
 //
+// Read uses a default Reader. Use NewReader to configure the size limit,
+// unknown field policy, number decoding, required Content-Type or empty
+// body handling.
+//
+// Failures caused by the request body are returned as a *ReadError which
+// classifies the problem (see ReadErrorKind) and carries a client-safe message.
+//
 // Parameters:
 //   - w: The http.ResponseWriter (used for MaxBytesReader)
 //   - r: The *http.Request containing the JSON body
@@ -99,31 +439,162 @@ func Write(w http.ResponseWriter, status int, data Envelope, headers http.Header
 //	    // Handle error...
 //	}
 func Read(w http.ResponseWriter, r *http.Request, dst any) error {
-	// TODO: make this value configurable
-	var max int64 = 1_048_576
+	return defaultReader.Read(w, r, dst)
+}
+
+// ReadOrError decodes a JSON request body like Read. If reading fails it
+// writes an error response with netio.Error and returns the error, so the
+// caller must not write to w again.
+//
+// The status code is chosen from the kind of failure (see ReadError.StatusCode)
+// and the client-safe message is placed in the validation map, keyed by the
+// offending field or "body" when the problem is not tied to a field.
+// Errors that are not caused by the request (e.g. a non-pointer dst) are
+// reported as 500 Internal Server Error without details.
+//
+// Parameters:
+//   - w: The http.ResponseWriter to write the error response to
+//   - r: The *http.Request containing the JSON body
+//   - dst: Non-nil pointer to the destination struct where the JSON will be decoded
+//
+// Example:
+//
+//	var input struct {
+//	    Name string `json:"name"`
+//	    Age  int    `json:"age"`
+//	}
+//	if err := netio.ReadOrError(w, r, &input); err != nil {
+//	    return
+//	}
+//
+// The JSON response for {"age": "thirty"}:
+//
+//	{
+//	    "error": {
+//	        "status": 422,
+//	        "message": "Unprocessable Entity",
+//	        "validation": {
+//	            "age": "body contains incorrect JSON type for field \"age\""
+//	        },
+//	        "timestamp": "2024-01-09T12:00:00Z"
+//	    }
+//	}
+func ReadOrError(w http.ResponseWriter, r *http.Request, dst any) error {
+	return defaultReader.ReadOrError(w, r, dst)
+}
 
-	// set maximum bytes to receive to prevent/mitigate DOS on API
-	http.MaxBytesReader(w, r.Body, max)
+// ReadAndValidate decodes a JSON request body like Read and then, if dst
+// implements Validatable, runs its Validate method with a fresh Validator.
+//
+// Failed validation is returned as a *ValidationError whose Validator can be
+// passed straight to netio.Error. Read failures are returned unchanged.
+//
+// Parameters:
+//   - w: The http.ResponseWriter (used for MaxBytesReader)
+//   - r: The *http.Request containing the JSON body
+//   - dst: Non-nil pointer to the destination struct where the JSON will be decoded
+//
+// Example:
+//
+//	var input signupInput // implements netio.Validatable
+//
+//	err := netio.ReadAndValidate(w, r, &input)
+//	var verr *netio.ValidationError
+//	switch {
+//	case errors.As(err, &verr):
+//	    netio.Error(w, "error", http.StatusUnprocessableEntity, verr.Validator)
+//	    return
+//	case err != nil:
+//	    netio.Error(w, "error", http.StatusBadRequest, nil)
+//	    return
+//	}
+func ReadAndValidate(w http.ResponseWriter, r *http.Request, dst any) error {
+	return defaultReader.ReadAndValidate(w, r, dst)
+}
 
-	// configure decoder settings
-	dec := json.NewDecoder(r.Body)
-	dec.DisallowUnknownFields()
+// ReadForm decodes an application/x-www-form-urlencoded or
+// multipart/form-data request body into the struct dst points to, so HTML
+// forms can share destination structs with JSON endpoints. It enforces the
+// same 1MB limit as Read. Use Reader.ReadForm to configure the limit.
+//
+// Fields are matched by their `form` tag, falling back to the `json` tag
+// and then the Go field name; fields tagged `form:"-"` are skipped. Nested
+// structs are filled from dotted names such as "address.postcode". Values
+// are converted to strings, bools ("on" counts as true for checkboxes),
+// integers, floats, time.Time (RFC 3339 or HTML date and datetime-local
+// values), time.Duration and types implementing encoding.TextUnmarshaler.
+// Slices receive every value sent for their name and pointers are only set
+// when a value is present. Fields without a value are left untouched, and
+// uploaded files are ignored.
+//
+// Values that cannot be converted are returned together as a
+// *ValidationError keyed like Validator.Struct keys (e.g. "age" or
+// "tags[1]"). Other failures caused by the request are returned as a
+// *ReadError. ReadForm panics if dst is not a non-nil pointer to a struct.
+//
+// Parameters:
+//   - w: The http.ResponseWriter (used for MaxBytesReader)
+//   - r: The *http.Request containing the form body
+//   - dst: Non-nil pointer to the destination struct
+//
+// Example:
+//
+//	var input struct {
+//	    Name      string    `form:"name"`
+//	    Age       int       `form:"age"`
+//	    Subscribe bool      `form:"subscribe"`
+//	    Birthday  time.Time `form:"birthday"`
+//	    Tags      []string  `form:"tag"`
+//	}
+//	if err := netio.ReadForm(w, r, &input); err != nil {
+//	    netio.WriteErr(w, err)
+//	    return
+//	}
+func ReadForm(w http.ResponseWriter, r *http.Request, dst any) error {
+	return defaultReader.ReadForm(w, r, dst)
+}
 
-	// decode request body to destination (dst any)
-	err := dec.Decode(dst)
-	if err != nil {
-		return fmt.Errorf("netio.Read(): %w", err)
-	}
+// queryBinder binds query parameters using the `query` struct tag.
+var queryBinder = binder{tag: "query", defaults: true, split: true, skipEmpty: true}
 
-	// try decode again into an anonymous dst
-	// look for io.EOF. This is to prevent multiple
-	// json bodies being used i.e.
-	// {"body1": "values"}{"body2": "values"}
-	s := &struct{}{}
-	err = dec.Decode(s)
-	if !errors.Is(err, io.EOF) {
-		return ErrMultipleJsonBodies
+// ReadQuery binds the query parameters of r into the struct dst points to.
+//
+// Fields are matched by their `query` tag, falling back to the `json` tag
+// and then the Go field name; fields tagged `query:"-"` are skipped. Values
+// are converted like ReadForm converts form fields. Slices collect repeated
+// parameters as well as comma separated lists (?status=active,pending).
+// Parameters that are missing or empty leave their field untouched, so
+// pointer fields stay nil and can be told apart from zero values, unless
+// the field has a `default` tag whose value is used instead.
+//
+// Values that cannot be converted are returned together as a
+// *ValidationError keyed by parameter name (e.g. "page" or "status[1]").
+// ReadQuery panics if dst is not a non-nil pointer to a struct or if a
+// `default` tag cannot be converted to its field's type.
+//
+// Parameters:
+//   - r: The *http.Request whose URL query is read
+//   - dst: Non-nil pointer to the destination struct
+//
+// Example:
+//
+//	// GET /orders?page=2&sort=-created_at&status=active&status=pending
+//	var q struct {
+//	    Page   int        `query:"page" default:"1"`
+//	    Limit  int        `query:"limit" default:"20"`
+//	    Sort   string     `query:"sort" default:"-created_at"`
+//	    Status []string   `query:"status"`
+//	    Since  *time.Time `query:"since"`
+//	}
+//	if err := netio.ReadQuery(r, &q); err != nil {
+//	    netio.WriteErr(w, err)
+//	    return
+//	}
+func ReadQuery(r *http.Request, dst any) error {
+	v := NewValidator()
+	queryBinder.bind(r.URL.Query(), dst, v)
+	if !v.Valid() {
+		return &ValidationError{Validator: v}
 	}
-
 	return nil
 }
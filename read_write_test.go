@@ -1,12 +1,17 @@
 package netio
 
 import (
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"slices"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestWrite(t *testing.T) {
@@ -49,12 +54,13 @@ func TestWrite(t *testing.T) {
 			wantStatus: http.StatusOK,
 		},
 		{
+			// nothing is written on failure, so the recorder keeps its default code
 			name:       "fail write with invalid data",
 			status:     http.StatusInternalServerError,
 			data:       Envelope{"data": func() {}},
 			headers:    nil,
 			wantErr:    true,
-			wantStatus: http.StatusInternalServerError,
+			wantStatus: http.StatusOK,
 		},
 	}
 
@@ -78,15 +84,28 @@ func TestWrite(t *testing.T) {
 				t.Errorf("Write() code = %v, want %v", w.Code, test.wantStatus)
 			}
 
+			// make sure nothing was sent when encoding failed
+			if test.wantErr {
+				if w.Body.Len() != 0 || len(w.Result().Header) != 0 {
+					t.Error("Write() wrote a response after failing to encode data")
+				}
+				return
+			}
+
 			// make sure application/json is always set
-			if w.Header().Get("Content-Type") != "application/json" {
+			if w.Result().Header.Get("Content-Type") != "application/json" {
 				t.Error("Write() did not set Content-Type header to application/json")
 			}
 
-			// check if header values are the same
+			// make sure Content-Length matches the body
+			if w.Result().Header.Get("Content-Length") != strconv.Itoa(w.Body.Len()) {
+				t.Error("Write() did not set Content-Length to the body size")
+			}
+
+			// check if header values were sent, not just set after the status
 			if test.headers != nil {
 				for key, values := range test.headers {
-					got := w.Header()[key]
+					got := w.Result().Header[key]
 					if !slices.Equal(got, values) {
 						t.Errorf("Write() headers don't match what was given")
 					}
@@ -94,10 +113,59 @@ func TestWrite(t *testing.T) {
 			}
 
 			// check if json encoded by Write can be decoded without errors
-			if !test.wantErr {
-				var gotData Envelope
-				if err := json.NewDecoder(w.Body).Decode(&gotData); err != nil {
-					t.Errorf("Write() invalid JSON response")
+			var gotData Envelope
+			if err := json.NewDecoder(w.Body).Decode(&gotData); err != nil {
+				t.Errorf("Write() invalid JSON response")
+			}
+		})
+	}
+}
+
+// shortWriter is a ResponseWriter that accepts at most limit bytes.
+type shortWriter struct {
+	*httptest.ResponseRecorder
+	limit int
+}
+
+func (sw *shortWriter) Write(b []byte) (int, error) {
+	if len(b) > sw.limit {
+		b = b[:sw.limit]
+	}
+	return sw.ResponseRecorder.Write(b)
+}
+
+func TestWrite_Errors(t *testing.T) {
+	t.Run("short write", func(t *testing.T) {
+		w := &shortWriter{ResponseRecorder: httptest.NewRecorder(), limit: 4}
+		err := Write(w, http.StatusOK, Envelope{"message": "success"}, nil)
+		if !errors.Is(err, io.ErrShortWrite) {
+			t.Errorf("Write() error = %v, want io.ErrShortWrite", err)
+		}
+	})
+
+	t.Run("caller headers override defaults", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		headers := http.Header{"X-Frame-Options": []string{"SAMEORIGIN"}}
+		if err := Write(w, http.StatusCreated, Envelope{"message": "success"}, headers); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		if got := w.Result().Header.Get("X-Frame-Options"); got != "SAMEORIGIN" {
+			t.Errorf("Write() X-Frame-Options = %q, want SAMEORIGIN", got)
+		}
+	})
+
+	for _, status := range []int{http.StatusNoContent, http.StatusNotModified} {
+		t.Run("no body for "+strconv.Itoa(status), func(t *testing.T) {
+			for _, opts := range [][]WriteOption{nil, {WithStreaming()}} {
+				w := httptest.NewRecorder()
+				if err := Write(w, status, nil, nil, opts...); err != nil {
+					t.Fatalf("Write() error = %v", err)
+				}
+				if w.Code != status || w.Body.Len() != 0 {
+					t.Errorf("Write() code = %v, body = %q, want %v without body", w.Code, w.Body.String(), status)
+				}
+				if cl := w.Header().Get("Content-Length"); cl != "" {
+					t.Errorf("Write() Content-Length = %q, want none", cl)
 				}
 			}
 		})
@@ -151,3 +219,313 @@ func TestRead(t *testing.T) {
 		})
 	}
 }
+
+func TestReadOrError(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		wantErr     bool
+		wantStatus  int
+		wantKey     string
+	}{
+		{"valid json", `{"name": "test", "age": 30}`, "", false, http.StatusOK, ""},
+		{"syntax error", `{"name": }`, "", true, http.StatusBadRequest, "body"},
+		{"type mismatch", `{"age": "thirty"}`, "", true, http.StatusUnprocessableEntity, "age"},
+		{"unknown field", `{"nickname": "x"}`, "", true, http.StatusBadRequest, "nickname"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.body))
+			w := httptest.NewRecorder()
+
+			var dst struct {
+				Name string `json:"name"`
+				Age  int    `json:"age"`
+			}
+
+			err := ReadOrError(w, r, &dst)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("ReadOrError() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if w.Code != test.wantStatus {
+				t.Errorf("ReadOrError() code = %v, want %v", w.Code, test.wantStatus)
+			}
+			if !test.wantErr {
+				return
+			}
+
+			var got struct {
+				Error struct {
+					Validation map[string]string `json:"validation"`
+				} `json:"error"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatalf("ReadOrError() invalid JSON response: %v", err)
+			}
+			if got.Error.Validation[test.wantKey] == "" {
+				t.Errorf("ReadOrError() validation = %v, want key %q", got.Error.Validation, test.wantKey)
+			}
+		})
+	}
+
+	t.Run("unsupported content type", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`))
+		r.Header.Set("Content-Type", "text/plain")
+		w := httptest.NewRecorder()
+
+		var dst struct{}
+		rd := NewReader(WithContentType("application/json"))
+		if err := rd.ReadOrError(w, r, &dst); err == nil {
+			t.Fatal("ReadOrError() expected error")
+		}
+		if w.Code != http.StatusUnsupportedMediaType {
+			t.Errorf("ReadOrError() code = %v, want %v", w.Code, http.StatusUnsupportedMediaType)
+		}
+	})
+}
+
+// textID is a byte array with its own text encoding, like uuid.UUID.
+type textID [4]byte
+
+func (id textID) MarshalText() ([]byte, error) {
+	return []byte(hex.EncodeToString(id[:])), nil
+}
+
+// joinedList is a slice with its own JSON encoding.
+type joinedList []string
+
+func (l joinedList) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strings.Join(l, ","))
+}
+
+func TestWrite_OutputMode(t *testing.T) {
+	type item struct {
+		ID   int      `json:"id"`
+		Tags []string `json:"tags"`
+	}
+	data := Envelope{
+		"items": []item{{1, []string{"a"}}, {2, nil}},
+		"count": 2,
+		"empty": []int{},
+		// lists with their own encoding must not be expanded when streaming
+		"id":     textID{1, 2, 3, 4},
+		"joined": joinedList{"a", "b"},
+		"raw":    [2]byte{1, 2},
+	}
+
+	tests := []struct {
+		name        string
+		mode        OutputMode
+		withRequest bool
+		target      string
+		userAgent   string
+		wantPretty  bool
+	}{
+		{"pretty", OutputPretty, false, "/", "", true},
+		{"compact", OutputCompact, false, "/", "", false},
+		{"auto without request", OutputAuto, false, "/", "curl/8.5.0", false},
+		{"auto with browser", OutputAuto, true, "/", "Mozilla/5.0", false},
+		{"auto with pretty query", OutputAuto, true, "/?pretty=true", "Mozilla/5.0", true},
+		{"auto with curl", OutputAuto, true, "/", "curl/8.5.0", true},
+		{"auto with curl and pretty=false", OutputAuto, true, "/?pretty=false", "curl/8.5.0", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, test.target, nil)
+			r.Header.Set("User-Agent", test.userAgent)
+			opts := []WriteOption{WithOutputMode(test.mode)}
+			if test.withRequest {
+				opts = append(opts, WithRequest(r))
+			}
+
+			want, _ := json.Marshal(data)
+			if test.wantPretty {
+				want, _ = json.MarshalIndent(data, "", "\t")
+			}
+			want = append(want, '\n')
+
+			for _, streaming := range []bool{false, true} {
+				w := httptest.NewRecorder()
+				callOpts := opts
+				if streaming {
+					callOpts = append(slices.Clone(opts), WithStreaming())
+				}
+				if err := Write(w, http.StatusOK, data, nil, callOpts...); err != nil {
+					t.Fatalf("Write() error = %v", err)
+				}
+				if w.Body.String() != string(want) {
+					t.Errorf("Write() streaming=%v body = %q, want %q", streaming, w.Body.String(), want)
+				}
+			}
+		})
+	}
+}
+
+// validatedInput is a Validatable test destination.
+type validatedInput struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+func (in validatedInput) Validate(v *Validator) {
+	v.Check(in.Name != "", "name", "must be provided")
+	v.Check(in.Age >= 18, "age", "must be over 18")
+}
+
+func TestReadAndValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantRead bool
+		wantKeys []string
+	}{
+		{"valid input", `{"name": "jack", "age": 30}`, false, nil},
+		{"read failure", `{"name": `, true, nil},
+		{"validation failure", `{"name": "", "age": 12}`, false, []string{"age", "name"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.body))
+			w := httptest.NewRecorder()
+
+			var dst validatedInput
+			err := ReadAndValidate(w, r, &dst)
+
+			var rerr *ReadError
+			if errors.As(err, &rerr) != test.wantRead {
+				t.Errorf("ReadAndValidate() error = %v, want read error %v", err, test.wantRead)
+			}
+
+			var verr *ValidationError
+			if errors.As(err, &verr) != (test.wantKeys != nil) {
+				t.Fatalf("ReadAndValidate() error = %v, want validation error %v", err, test.wantKeys != nil)
+			}
+			for _, key := range test.wantKeys {
+				if _, ok := verr.Validator.Errors[key]; !ok {
+					t.Errorf("ReadAndValidate() missing validation error for %q", key)
+				}
+			}
+			if verr != nil && verr.StatusCode() != http.StatusUnprocessableEntity {
+				t.Errorf("ValidationError.StatusCode() = %v, want 422", verr.StatusCode())
+			}
+		})
+	}
+}
+
+func TestReadQuery(t *testing.T) {
+	type query struct {
+		Page   int        `query:"page" default:"1"`
+		Limit  int        `query:"limit" default:"20"`
+		Sort   string     `query:"sort" default:"-created_at"`
+		Status []string   `query:"status"`
+		IDs    []int      `json:"id"`
+		Since  *time.Time `query:"since"`
+		Active *bool      `query:"active"`
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		want      query
+		wantSince time.Time
+		wantErrs  map[string]string
+	}{
+		{
+			name:  "defaults",
+			query: "",
+			want:  query{Page: 1, Limit: 20, Sort: "-created_at"},
+		},
+		{
+			name:      "values",
+			query:     "page=2&limit=50&sort=name&status=active&status=pending,closed&id=1,2&since=2024-01-09",
+			want:      query{Page: 2, Limit: 50, Sort: "name", Status: []string{"active", "pending", "closed"}, IDs: []int{1, 2}},
+			wantSince: time.Date(2024, 1, 9, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "empty values use defaults",
+			query: "page=&status=",
+			want:  query{Page: 1, Limit: 20, Sort: "-created_at"},
+		},
+		{
+			name:  "conversion errors",
+			query: "page=two&id=1,x&since=yesterday&active=maybe",
+			wantErrs: map[string]string{
+				"page":   "must be an integer",
+				"id[1]":  "must be an integer",
+				"since":  "must be a valid date or time",
+				"active": "must be a boolean",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/orders?"+tc.query, nil)
+
+			var got query
+			err := ReadQuery(r, &got)
+
+			if tc.wantErrs != nil {
+				var verr *ValidationError
+				if !errors.As(err, &verr) {
+					t.Fatalf("ReadQuery() error = %v, want *ValidationError", err)
+				}
+				for key, msg := range tc.wantErrs {
+					if verr.Validator.Errors[key] != msg {
+						t.Errorf("ReadQuery() errors[%q] = %q, want %q", key, verr.Validator.Errors[key], msg)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadQuery() error = %v", err)
+			}
+
+			if got.Page != tc.want.Page || got.Limit != tc.want.Limit || got.Sort != tc.want.Sort ||
+				!slices.Equal(got.Status, tc.want.Status) || !slices.Equal(got.IDs, tc.want.IDs) {
+				t.Errorf("ReadQuery() = %+v, want %+v", got, tc.want)
+			}
+			if tc.wantSince.IsZero() != (got.Since == nil) || got.Since != nil && !got.Since.Equal(tc.wantSince) {
+				t.Errorf("ReadQuery() since = %v, want %v", got.Since, tc.wantSince)
+			}
+			if got.Active != nil {
+				t.Errorf("ReadQuery() active = %v, want nil when missing", *got.Active)
+			}
+		})
+	}
+
+	t.Run("embedded pointer", func(t *testing.T) {
+		type Pagination struct {
+			Page  int `query:"page" default:"1"`
+			Limit int `query:"limit" default:"20"`
+		}
+		type search struct {
+			*Pagination
+			Q string `query:"q"`
+		}
+
+		var got search
+		if err := ReadQuery(httptest.NewRequest(http.MethodGet, "/?q=go&page=3", nil), &got); err != nil {
+			t.Fatalf("ReadQuery() error = %v", err)
+		}
+		if got.Pagination == nil || got.Page != 3 || got.Limit != 20 || got.Q != "go" {
+			t.Errorf("ReadQuery() = %+v, want page 3, limit 20 and q go", got)
+		}
+	})
+
+	t.Run("invalid default", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("ReadQuery() did not panic for an invalid default")
+			}
+		}()
+		var q struct {
+			Page int `query:"page" default:"first"`
+		}
+		ReadQuery(httptest.NewRequest(http.MethodGet, "/", nil), &q)
+	})
+}
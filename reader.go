@@ -0,0 +1,264 @@
+package netio
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// DefaultMaxBytes is the maximum request body size accepted by a Reader
+// when no WithMaxBytes option is given (1MB).
+const DefaultMaxBytes int64 = 1_048_576
+
+var (
+	// ErrEmptyBody is returned when the request body is empty and the
+	// Reader has not been configured to allow empty bodies.
+	ErrEmptyBody = errors.New("body must not be empty")
+	// ErrUnsupportedContentType is returned when the request Content-Type
+	// does not match the media type required by the Reader.
+	ErrUnsupportedContentType = errors.New("unsupported content type")
+)
+
+// Reader decodes JSON request bodies using a fixed set of options.
+// A Reader is safe for concurrent use and is usually created once per
+// group of endpoints that share the same limits.
+//
+// The zero value is not usable; create Readers with NewReader.
+type Reader struct {
+	maxBytes     int64
+	allowUnknown bool
+	useNumber    bool
+	contentType  string
+	allowEmpty   bool
+}
+
+// ReadOption configures a Reader created by NewReader.
+type ReadOption func(*Reader)
+
+// WithMaxBytes sets the maximum number of bytes read from the request body.
+// Values less than or equal to zero are ignored.
+func WithMaxBytes(n int64) ReadOption {
+	return func(rd *Reader) {
+		if n > 0 {
+			rd.maxBytes = n
+		}
+	}
+}
+
+// WithAllowUnknownFields makes the Reader ignore JSON fields that do not
+// exist on the destination struct instead of returning an error.
+func WithAllowUnknownFields() ReadOption {
+	return func(rd *Reader) {
+		rd.allowUnknown = true
+	}
+}
+
+// WithUseNumber makes the Reader decode numbers into json.Number instead of
+// float64 when the destination is an interface{} value.
+func WithUseNumber() ReadOption {
+	return func(rd *Reader) {
+		rd.useNumber = true
+	}
+}
+
+// WithContentType requires the request Content-Type header to match the
+// given media type (e.g. "application/json"). Parameters such as charset
+// are ignored and media types are compared case-insensitively. ReadForm
+// does not use it.
+func WithContentType(mediaType string) ReadOption {
+	return func(rd *Reader) {
+		// mime.ParseMediaType lowercases the request's media type
+		rd.contentType = strings.ToLower(strings.TrimSpace(mediaType))
+	}
+}
+
+// WithAllowEmpty makes the Reader treat an empty request body as a no-op,
+// leaving dst untouched, instead of returning ErrEmptyBody.
+func WithAllowEmpty() ReadOption {
+	return func(rd *Reader) {
+		rd.allowEmpty = true
+	}
+}
+
+// NewReader creates a Reader configured with the given options.
+// Without options it behaves exactly like netio.Read: a 1MB body limit,
+// unknown fields are rejected, any Content-Type is accepted and an empty
+// body is an error.
+//
+// Example:
+//
+//	// uploads accept larger bodies and tolerate extra fields
+//	uploads := netio.NewReader(
+//	    netio.WithMaxBytes(10<<20),
+//	    netio.WithAllowUnknownFields(),
+//	)
+//
+//	// auth endpoints are tiny and strict
+//	auth := netio.NewReader(
+//	    netio.WithMaxBytes(4<<10),
+//	    netio.WithContentType("application/json"),
+//	)
+//
+//	if err := auth.Read(w, r, &input); err != nil {
+//	    // Handle error...
+//	}
+func NewReader(opts ...ReadOption) *Reader {
+	rd := &Reader{
+		maxBytes: DefaultMaxBytes,
+	}
+	for _, opt := range opts {
+		opt(rd)
+	}
+	return rd
+}
+
+// defaultReader backs the package level Read function.
+var defaultReader = NewReader()
+
+// Read decodes a JSON request body into the provided destination using the
+// Reader's configuration. It validates that only a single JSON value is
+// present in the request body.
+//
+// Failures caused by the request body are returned as a *ReadError.
+//
+// Parameters:
+//   - w: The http.ResponseWriter (used for MaxBytesReader)
+//   - r: The *http.Request containing the JSON body
+//   - dst: Non-nil pointer to the destination where the JSON will be decoded
+func (rd *Reader) Read(w http.ResponseWriter, r *http.Request, dst any) error {
+	if err := rd.checkContentType(r); err != nil {
+		return err
+	}
+
+	// set maximum bytes to receive to prevent/mitigate DOS on API
+	r.Body = http.MaxBytesReader(w, r.Body, rd.maxBytes)
+
+	dec := rd.newDecoder(r.Body)
+
+	// decode request body to destination (dst any)
+	err := dec.Decode(dst)
+	if err != nil {
+		if errors.Is(err, io.EOF) && rd.allowEmpty {
+			return nil
+		}
+		return classifyDecodeError(err, dec)
+	}
+
+	// try decode again into an anonymous dst
+	// look for io.EOF. This is to prevent multiple
+	// json bodies being used i.e.
+	// {"body1": "values"}{"body2": "values"}
+	s := &struct{}{}
+	err = dec.Decode(s)
+	if !errors.Is(err, io.EOF) {
+		// a body that is too large is more useful to report than
+		// the fact that there was trailing data
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return classifyDecodeError(err, dec)
+		}
+		return &ReadError{Kind: KindMultipleValues, Offset: dec.InputOffset(), Err: ErrMultipleJsonBodies}
+	}
+
+	return nil
+}
+
+// formBinder binds form fields using the `form` struct tag.
+var formBinder = binder{tag: "form"}
+
+// maxFormMemory is the largest part of a multipart body kept in memory;
+// larger file parts are stored in temporary files by net/http.
+const maxFormMemory = 32 << 20
+
+// ReadForm decodes an application/x-www-form-urlencoded or
+// multipart/form-data request body into the struct dst points to, under the
+// same size limit as Read. The WithContentType option does not apply, as
+// forms always use one of those two media types, so a Reader configured
+// for JSON can read forms too. See the package level ReadForm for details.
+func (rd *Reader) ReadForm(w http.ResponseWriter, r *http.Request, dst any) error {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/x-www-form-urlencoded" && mediaType != "multipart/form-data" {
+		return &ReadError{
+			Kind: KindContentType,
+			Err:  fmt.Errorf("%w: expected application/x-www-form-urlencoded or multipart/form-data", ErrUnsupportedContentType),
+		}
+	}
+
+	// set maximum bytes to receive to prevent/mitigate DOS on API
+	r.Body = http.MaxBytesReader(w, r.Body, rd.maxBytes)
+
+	var err error
+	if mediaType == "multipart/form-data" {
+		err = r.ParseMultipartForm(min(rd.maxBytes, maxFormMemory))
+	} else {
+		err = r.ParseForm()
+	}
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return &ReadError{Kind: KindTooLarge, Offset: maxErr.Limit, Err: err}
+		}
+		return &ReadError{Kind: KindMalformedForm, Err: err}
+	}
+
+	v := NewValidator()
+	formBinder.bind(r.PostForm, dst, v)
+	if !v.Valid() {
+		return &ValidationError{Validator: v}
+	}
+	return nil
+}
+
+// checkContentType returns a *ReadError if r does not carry the media type
+// required by the Reader.
+func (rd *Reader) checkContentType(r *http.Request) error {
+	if rd.contentType == "" {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != rd.contentType {
+		return &ReadError{
+			Kind: KindContentType,
+			Err:  fmt.Errorf("%w: expected %s", ErrUnsupportedContentType, rd.contentType),
+		}
+	}
+	return nil
+}
+
+// newDecoder returns a decoder reading from body with the Reader's settings.
+func (rd *Reader) newDecoder(body io.Reader) *json.Decoder {
+	dec := json.NewDecoder(body)
+	if !rd.allowUnknown {
+		dec.DisallowUnknownFields()
+	}
+	if rd.useNumber {
+		dec.UseNumber()
+	}
+	return dec
+}
+
+// ReadOrError behaves like Read but also writes an error response through
+// netio.Error when reading fails, so the handler only has to return.
+// See the package level ReadOrError for details.
+func (rd *Reader) ReadOrError(w http.ResponseWriter, r *http.Request, dst any) error {
+	err := rd.Read(w, r, dst)
+	if err == nil {
+		return nil
+	}
+
+	writeErr(w, r, err)
+	return err
+}
+
+// ReadAndValidate behaves like Read and then validates dst if it implements
+// Validatable. See the package level ReadAndValidate for details.
+func (rd *Reader) ReadAndValidate(w http.ResponseWriter, r *http.Request, dst any) error {
+	if err := rd.Read(w, r, dst); err != nil {
+		return err
+	}
+	return validate(dst)
+}
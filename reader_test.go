@@ -0,0 +1,214 @@
+package netio
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReader_Read(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []ReadOption
+		body        string
+		contentType string
+		wantErr     error
+		wantAnyErr  bool
+	}{
+		{
+			name: "default reader accepts valid json",
+			body: `{"name": "test"}`,
+		},
+		{
+			name:       "default reader rejects unknown fields",
+			body:       `{"name": "test", "extra": true}`,
+			wantAnyErr: true,
+		},
+		{
+			name: "allow unknown fields",
+			opts: []ReadOption{WithAllowUnknownFields()},
+			body: `{"name": "test", "extra": true}`,
+		},
+		{
+			name:       "body larger than max bytes",
+			opts:       []ReadOption{WithMaxBytes(8)},
+			body:       `{"name": "a much longer value"}`,
+			wantAnyErr: true,
+		},
+		{
+			name:    "empty body rejected by default",
+			body:    "",
+			wantErr: ErrEmptyBody,
+		},
+		{
+			name: "empty body allowed",
+			opts: []ReadOption{WithAllowEmpty()},
+			body: "",
+		},
+		{
+			name:        "required content type matches",
+			opts:        []ReadOption{WithContentType("application/json")},
+			body:        `{"name": "test"}`,
+			contentType: "application/json; charset=utf-8",
+		},
+		{
+			name:        "required content type is case-insensitive",
+			opts:        []ReadOption{WithContentType("Application/JSON")},
+			body:        `{"name": "test"}`,
+			contentType: "application/JSON",
+		},
+		{
+			name:        "required content type mismatch",
+			opts:        []ReadOption{WithContentType("application/json")},
+			body:        `{"name": "test"}`,
+			contentType: "text/plain",
+			wantErr:     ErrUnsupportedContentType,
+		},
+		{
+			name:    "multiple json values",
+			body:    `{"name": "a"}{"name": "b"}`,
+			wantErr: ErrMultipleJsonBodies,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.body))
+			if test.contentType != "" {
+				r.Header.Set("Content-Type", test.contentType)
+			}
+			w := httptest.NewRecorder()
+
+			var dst struct {
+				Name string `json:"name"`
+			}
+
+			err := NewReader(test.opts...).Read(w, r, &dst)
+
+			if test.wantErr != nil {
+				if !errors.Is(err, test.wantErr) {
+					t.Errorf("Read() error = %v, want %v", err, test.wantErr)
+				}
+				return
+			}
+			if (err != nil) != test.wantAnyErr {
+				t.Errorf("Read() error = %v, wantErr %v", err, test.wantAnyErr)
+			}
+		})
+	}
+}
+
+func TestReader_UseNumber(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"id": 12345678901234567890}`))
+	w := httptest.NewRecorder()
+
+	var dst map[string]any
+	if err := NewReader(WithUseNumber()).Read(w, r, &dst); err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if _, ok := dst["id"].(json.Number); !ok {
+		t.Errorf("Read() id decoded as %T, want json.Number", dst["id"])
+	}
+}
+
+func TestReader_ReadForm(t *testing.T) {
+	type input struct {
+		Name string   `form:"name"`
+		Age  int      `json:"age"`
+		Tags []string `form:"tag"`
+	}
+
+	var multipartBody bytes.Buffer
+	mw := multipart.NewWriter(&multipartBody)
+	mw.WriteField("name", "Alice")
+	mw.WriteField("age", "30")
+	mw.WriteField("tag", "a")
+	mw.WriteField("tag", "b")
+	mw.Close()
+
+	tests := []struct {
+		name        string
+		opts        []ReadOption
+		body        string
+		contentType string
+		wantKind    ReadErrorKind
+		wantInvalid string
+	}{
+		{
+			name:        "urlencoded",
+			body:        "name=Alice&age=30&tag=a&tag=b",
+			contentType: "application/x-www-form-urlencoded",
+		},
+		{
+			name:        "multipart",
+			body:        multipartBody.String(),
+			contentType: mw.FormDataContentType(),
+		},
+		{
+			name:        "json content type",
+			body:        `{"name":"Alice"}`,
+			contentType: "application/json",
+			wantKind:    KindContentType,
+		},
+		{
+			name:        "json reader reads forms",
+			opts:        []ReadOption{WithContentType("application/json")},
+			body:        "name=Alice&age=30&tag=a&tag=b",
+			contentType: "application/x-www-form-urlencoded",
+		},
+		{
+			name:        "too large",
+			opts:        []ReadOption{WithMaxBytes(8)},
+			body:        "name=Alice&age=30",
+			contentType: "application/x-www-form-urlencoded",
+			wantKind:    KindTooLarge,
+		},
+		{
+			name:        "malformed",
+			body:        "name=%zz",
+			contentType: "application/x-www-form-urlencoded",
+			wantKind:    KindMalformedForm,
+		},
+		{
+			name:        "conversion error",
+			body:        "name=Alice&age=thirty",
+			contentType: "application/x-www-form-urlencoded",
+			wantInvalid: "age",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+			r.Header.Set("Content-Type", tc.contentType)
+
+			var in input
+			err := NewReader(tc.opts...).ReadForm(httptest.NewRecorder(), r, &in)
+
+			var rerr *ReadError
+			var verr *ValidationError
+			switch {
+			case tc.wantKind != 0:
+				if !errors.As(err, &rerr) || rerr.Kind != tc.wantKind {
+					t.Errorf("ReadForm() error = %v, want kind %v", err, tc.wantKind)
+				}
+			case tc.wantInvalid != "":
+				if !errors.As(err, &verr) || verr.Validator.Errors[tc.wantInvalid] == "" {
+					t.Errorf("ReadForm() error = %v, want validation error for %q", err, tc.wantInvalid)
+				}
+			case err != nil:
+				t.Fatalf("ReadForm() error = %v", err)
+			default:
+				if in.Name != "Alice" || in.Age != 30 || !slices.Equal(in.Tags, []string{"a", "b"}) {
+					t.Errorf("ReadForm() = %+v", in)
+				}
+			}
+		})
+	}
+}
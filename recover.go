@@ -0,0 +1,162 @@
+package netio
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"runtime/debug"
+)
+
+// RecoverConfig configures the middleware returned by RecoverWithConfig.
+type RecoverConfig struct {
+	// Logger receives a record with the panic value and stack trace for
+	// every recovered panic (defaults to slog.Default())
+	Logger *slog.Logger
+}
+
+// Recover is middleware that recovers from panics in next and responds with
+// a 500 JSON error instead of dropping the connection. See RecoverWithConfig
+// for details.
+//
+// Example:
+//
+//	log.Fatal(http.ListenAndServe(":8080", netio.RequestID(netio.Recover(mux))))
+func Recover(next http.Handler) http.Handler {
+	return RecoverWithConfig(RecoverConfig{})(next)
+}
+
+// RecoverWithConfig returns panic recovery middleware using cfg.
+//
+// A recovered panic is logged at slog.LevelError with the message
+// "panic recovered" and the attributes panic, method, path, request_id and
+// stack. If the handler has not written anything yet, a generic 500 error is
+// written with ErrorWith, so the response uses the configured error format
+// and never includes the panic value. Headers the handler set to describe
+// its own body (e.g. Content-Encoding or Content-Disposition) are removed
+// first. If the response had already started,
+// the connection is aborted instead, as a second response cannot be sent.
+//
+// Panics with http.ErrAbortHandler are passed through untouched, so handlers
+// can still abort a response deliberately.
+//
+// Example:
+//
+//	mw := netio.RecoverWithConfig(netio.RecoverConfig{
+//	    Logger: slog.New(slog.NewJSONHandler(os.Stderr, nil)),
+//	})
+//	log.Fatal(http.ListenAndServe(":8080", mw(mux)))
+func RecoverWithConfig(cfg RecoverConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := &recoverWriter{ResponseWriter: w}
+
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				logger := cfg.Logger
+				if logger == nil {
+					logger = slog.Default()
+				}
+				attrs := []slog.Attr{
+					slog.Any("panic", rec),
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+				}
+				if id := requestID(r); id != "" {
+					attrs = append(attrs, slog.String("request_id", id))
+				}
+				attrs = append(attrs, slog.String("stack", string(debug.Stack())))
+				logger.LogAttrs(r.Context(), slog.LevelError, "panic recovered", attrs...)
+
+				if rw.wroteHeader {
+					// part of the response is already on the wire
+					panic(http.ErrAbortHandler)
+				}
+				// headers describing the body the handler meant to send
+				// would mislabel the error response
+				for _, key := range bodyHeaders {
+					w.Header().Del(key)
+				}
+				ErrorWith(w, http.StatusInternalServerError, ErrorOptions{
+					Request: r,
+					Cause:   fmt.Errorf("panic: %v", rec),
+				})
+			}()
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// bodyHeaders are removed before Recover writes its error response. Other
+// headers, such as those set by upstream middleware, are kept.
+var bodyHeaders = []string{
+	"Content-Encoding",
+	"Content-Disposition",
+	"Content-Range",
+	"ETag",
+	"Last-Modified",
+	"Cache-Control",
+	"Trailer",
+}
+
+// recoverWriter records whether the response has started so Recover knows
+// whether an error response can still be written.
+type recoverWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (rw *recoverWriter) WriteHeader(status int) {
+	// informational responses do not start the final response
+	if status >= 200 {
+		rw.wroteHeader = true
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *recoverWriter) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
+	return rw.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher. Flushing sends the headers, so the
+// response counts as started.
+func (rw *recoverWriter) Flush() {
+	rw.wroteHeader = true
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker, e.g. for WebSocket upgrades. The
+// connection belongs to the handler afterwards, so the response counts as
+// started.
+func (rw *recoverWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := http.NewResponseController(rw.ResponseWriter).Hijack()
+	if err == nil {
+		rw.wroteHeader = true
+	}
+	return conn, brw, err
+}
+
+// ReadFrom implements io.ReaderFrom so responses copied with io.Copy keep
+// using the underlying writer's optimisations (e.g. sendfile).
+func (rw *recoverWriter) ReadFrom(src io.Reader) (int64, error) {
+	rw.wroteHeader = true
+	return io.Copy(rw.ResponseWriter, src)
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (rw *recoverWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -0,0 +1,151 @@
+package netio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecover(t *testing.T) {
+	var logs bytes.Buffer
+	mw := RecoverWithConfig(RecoverConfig{
+		Logger: slog.New(slog.NewJSONHandler(&logs, nil)),
+	})
+
+	t.Run("before write", func(t *testing.T) {
+		logs.Reset()
+		h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("secret internal detail")
+		}))
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("Recover() code = %v, want 500", w.Code)
+		}
+		var got map[string]ErrorResponse
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatalf("Recover() invalid JSON response: %v", err)
+		}
+		if got["error"].Message != "Internal Server Error" {
+			t.Errorf("Recover() message = %q, want status text", got["error"].Message)
+		}
+
+		var record map[string]any
+		if err := json.Unmarshal(logs.Bytes(), &record); err != nil {
+			t.Fatalf("Recover() invalid log record: %v", err)
+		}
+		if record["panic"] != "secret internal detail" || record["path"] != "/orders" {
+			t.Errorf("Recover() log record = %v", record)
+		}
+		if stack, _ := record["stack"].(string); !strings.Contains(stack, "recover_test.go") {
+			t.Error("Recover() log record does not include the stack trace")
+		}
+	})
+
+	t.Run("body headers", func(t *testing.T) {
+		h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Header().Set("Content-Disposition", "attachment; filename=report.csv")
+			w.Header().Set("Cache-Control", "max-age=3600")
+			panic("boom")
+		}))
+
+		w := httptest.NewRecorder()
+		w.Header().Set("X-Request-ID", "req-123")
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/report", nil))
+
+		for _, key := range []string{"Content-Encoding", "Content-Disposition", "Cache-Control"} {
+			if got := w.Header().Get(key); got != "" {
+				t.Errorf("Recover() %s = %q, want it removed", key, got)
+			}
+		}
+		if got := w.Header().Get("X-Request-ID"); got != "req-123" {
+			t.Errorf("Recover() X-Request-ID = %q, want upstream header kept", got)
+		}
+		if !json.Valid(w.Body.Bytes()) {
+			t.Errorf("Recover() body = %q, want JSON", w.Body.String())
+		}
+	})
+
+	t.Run("after write", func(t *testing.T) {
+		h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("partial"))
+			panic("boom")
+		}))
+
+		w := httptest.NewRecorder()
+		defer func() {
+			if rec := recover(); rec != http.ErrAbortHandler {
+				t.Errorf("Recover() panic = %v, want http.ErrAbortHandler", rec)
+			}
+			if w.Body.String() != "partial" {
+				t.Errorf("Recover() body = %q, want no second response", w.Body.String())
+			}
+		}()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+
+	t.Run("abort handler", func(t *testing.T) {
+		logs.Reset()
+		h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		}))
+
+		w := httptest.NewRecorder()
+		defer func() {
+			if rec := recover(); rec != http.ErrAbortHandler {
+				t.Errorf("Recover() panic = %v, want http.ErrAbortHandler", rec)
+			}
+			if logs.Len() != 0 || w.Body.Len() != 0 {
+				t.Error("Recover() handled http.ErrAbortHandler")
+			}
+		}()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+
+	t.Run("hijack", func(t *testing.T) {
+		h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hj, ok := w.(http.Hijacker)
+			if !ok {
+				t.Fatal("Recover() hides http.Hijacker")
+			}
+			conn, _, err := hj.Hijack()
+			if err != nil {
+				t.Fatalf("Hijack() error = %v", err)
+			}
+			defer conn.Close()
+			panic("boom")
+		}))
+
+		w := &hijackRecorder{ResponseRecorder: httptest.NewRecorder()}
+		defer func() {
+			if rec := recover(); rec != http.ErrAbortHandler {
+				t.Errorf("Recover() panic = %v, want http.ErrAbortHandler", rec)
+			}
+			if w.Body.Len() != 0 {
+				t.Errorf("Recover() body = %q, want nothing written after Hijack", w.Body.String())
+			}
+		}()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ws", nil))
+	})
+}
+
+// hijackRecorder is a ResponseRecorder that supports http.Hijacker.
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (w *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	server, client := net.Pipe()
+	client.Close()
+	return server, bufio.NewReadWriter(bufio.NewReader(server), bufio.NewWriter(server)), nil
+}
@@ -0,0 +1,157 @@
+package netio
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+)
+
+// DefaultRequestIDHeader is the header used by RequestID when no other
+// header is configured.
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength is the longest incoming request ID that is trusted.
+const maxRequestIDLength = 128
+
+// requestIDKey is the context key under which the request ID is stored.
+type requestIDKey struct{}
+
+// RequestIDConfig configures the middleware returned by RequestIDWithConfig.
+type RequestIDConfig struct {
+	// Header is the request and response header carrying the ID
+	// (defaults to DefaultRequestIDHeader)
+	Header string
+	// Generator creates IDs for requests that do not carry a valid one
+	// (defaults to NewUUIDv4)
+	Generator func() string
+}
+
+// RequestID is middleware that gives every request an ID for correlating
+// client reports with server logs. It uses the X-Request-ID header and
+// UUIDv4 IDs, see RequestIDWithConfig for details.
+//
+// Example:
+//
+//	mux := http.NewServeMux()
+//	...
+//	log.Fatal(http.ListenAndServe(":8080", netio.RequestID(mux)))
+func RequestID(next http.Handler) http.Handler {
+	return RequestIDWithConfig(RequestIDConfig{})(next)
+}
+
+// RequestIDWithConfig returns request ID middleware using cfg.
+//
+// The ID is taken from the configured request header when it holds at most
+// 128 printable ASCII characters, otherwise a new one is generated. The ID
+// is stored in the request context (see RequestIDFromContext) and echoed in
+// the response header. Error responses written by ErrorWith for that request
+// include it as "request_id", and the error logger records it.
+//
+// Example:
+//
+//	mw := netio.RequestIDWithConfig(netio.RequestIDConfig{
+//	    Header:    "X-Correlation-ID",
+//	    Generator: netio.NewULID,
+//	})
+//	log.Fatal(http.ListenAndServe(":8080", mw(mux)))
+func RequestIDWithConfig(cfg RequestIDConfig) func(http.Handler) http.Handler {
+	if cfg.Header == "" {
+		cfg.Header = DefaultRequestIDHeader
+	}
+	if cfg.Generator == nil {
+		cfg.Generator = NewUUIDv4
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(cfg.Header)
+			if !validRequestID(id) {
+				id = cfg.Generator()
+			}
+
+			w.Header().Set(cfg.Header, id)
+			ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequestIDFromContext returns the request ID stored by the RequestID
+// middleware, or an empty string if there is none.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// validRequestID reports whether an incoming ID can be trusted. Limiting
+// IDs to short printable ASCII keeps them safe to log and echo.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// NewUUIDv4 returns a random (version 4) UUID generated with crypto/rand,
+// e.g. "9b2c6f1e-3d4a-4f6b-8c2d-1e0f9a8b7c6d".
+func NewUUIDv4() string {
+	var b [16]byte
+	rand.Read(b[:])
+
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // variant 10
+
+	var out [36]byte
+	hex.Encode(out[0:8], b[0:4])
+	out[8] = '-'
+	hex.Encode(out[9:13], b[4:6])
+	out[13] = '-'
+	hex.Encode(out[14:18], b[6:8])
+	out[18] = '-'
+	hex.Encode(out[19:23], b[8:10])
+	out[23] = '-'
+	hex.Encode(out[24:], b[10:])
+	return string(out[:])
+}
+
+// crockford is the base32 alphabet used by ULIDs.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewULID returns a ULID: a 48-bit millisecond timestamp followed by 80
+// random bits from crypto/rand, encoded as 26 characters of Crockford
+// base32, e.g. "01HQ3Z8N6Y4V7K2M9R5T0W1XJE". ULIDs sort by creation time.
+func NewULID() string {
+	var b [16]byte
+	ms := uint64(time.Now().UnixMilli())
+	for i := 5; i >= 0; i-- {
+		b[i] = byte(ms)
+		ms >>= 8
+	}
+	rand.Read(b[6:])
+
+	// 128 bits encoded 5 bits at a time, most significant first; the
+	// first character only carries the top 3 bits
+	var out [26]byte
+	var acc uint32
+	var bits uint
+	pos := 25
+	for i := 15; i >= 0; i-- {
+		acc |= uint32(b[i]) << bits
+		bits += 8
+		for bits >= 5 {
+			out[pos] = crockford[acc&0x1f]
+			pos--
+			acc >>= 5
+			bits -= 5
+		}
+	}
+	out[0] = crockford[acc&0x1f]
+	return string(out[:])
+}
@@ -0,0 +1,163 @@
+package netio
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var (
+	uuidRX = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	ulidRX = regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		wantSame bool
+	}{
+		{"generated", "", false},
+		{"incoming", "req-123", true},
+		{"too long", strings.Repeat("a", 129), false},
+		{"control characters", "req\n123", false},
+		{"spaces", "req 123", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var seen string
+			h := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = RequestIDFromContext(r.Context())
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.incoming != "" {
+				r.Header.Set("X-Request-ID", test.incoming)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if got := w.Header().Get("X-Request-ID"); got != seen {
+				t.Errorf("RequestID() response header = %q, context = %q", got, seen)
+			}
+			if test.wantSame && seen != test.incoming {
+				t.Errorf("RequestID() id = %q, want %q", seen, test.incoming)
+			}
+			if !test.wantSame && !uuidRX.MatchString(seen) {
+				t.Errorf("RequestID() id = %q, want a generated UUIDv4", seen)
+			}
+		})
+	}
+}
+
+func TestRequestIDWithConfig(t *testing.T) {
+	mw := RequestIDWithConfig(RequestIDConfig{
+		Header:    "X-Correlation-ID",
+		Generator: NewULID,
+	})
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ErrorWith(w, http.StatusNotFound, ErrorOptions{Request: r})
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	id := w.Header().Get("X-Correlation-ID")
+	if !ulidRX.MatchString(id) {
+		t.Fatalf("RequestIDWithConfig() id = %q, want a ULID", id)
+	}
+
+	var got map[string]ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("ErrorWith() invalid JSON response: %v", err)
+	}
+	if got["error"].RequestID != id {
+		t.Errorf("ErrorWith() request_id = %q, want %q", got["error"].RequestID, id)
+	}
+}
+
+func TestErrorWith_RequestID(t *testing.T) {
+	t.Run("without request", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		ErrorWith(w, http.StatusNotFound, ErrorOptions{})
+		if strings.Contains(w.Body.String(), "request_id") {
+			t.Errorf("ErrorWith() body = %s, want no request_id", w.Body.String())
+		}
+	})
+
+	t.Run("problem", func(t *testing.T) {
+		SetErrorFormat(ErrorFormatProblem)
+		defer SetErrorFormat(ErrorFormatEnvelope)
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-Request-ID", "req-123")
+		w := httptest.NewRecorder()
+		ErrorWith(w, http.StatusNotFound, ErrorOptions{Request: r})
+
+		var got map[string]any
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatalf("ErrorWith() invalid JSON response: %v", err)
+		}
+		if got["request_id"] != "req-123" {
+			t.Errorf("ErrorWith() request_id = %v, want req-123", got["request_id"])
+		}
+	})
+}
+
+func TestRequestID_ErrorResponses(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"WriteErrRequest", func(w http.ResponseWriter, r *http.Request) {
+			WriteErrRequest(w, r, NewHTTPError(http.StatusNotFound, "user_not_found", "user does not exist"))
+		}},
+		{"ErrorRequest", func(w http.ResponseWriter, r *http.Request) {
+			ErrorRequest(w, r, "error", http.StatusNotFound, nil)
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			w := httptest.NewRecorder()
+			RequestID(test.handler).ServeHTTP(w, r)
+
+			var got struct {
+				Error ErrorResponse `json:"error"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatalf("invalid JSON response: %v", err)
+			}
+			id := w.Header().Get(DefaultRequestIDHeader)
+			if id == "" || got.Error.RequestID != id {
+				t.Errorf("request_id = %q, want %q", got.Error.RequestID, id)
+			}
+		})
+	}
+}
+
+func TestNewIDs(t *testing.T) {
+	if id := NewUUIDv4(); !uuidRX.MatchString(id) {
+		t.Errorf("NewUUIDv4() = %q, want a UUIDv4", id)
+	}
+	if NewUUIDv4() == NewUUIDv4() {
+		t.Error("NewUUIDv4() returned the same ID twice")
+	}
+
+	a, b := NewULID(), NewULID()
+	if !ulidRX.MatchString(a) {
+		t.Errorf("NewULID() = %q, want a ULID", a)
+	}
+	if a == b {
+		t.Error("NewULID() returned the same ID twice")
+	}
+	// the first 10 characters encode the timestamp
+	if a[:10] > b[:10] {
+		t.Errorf("NewULID() timestamps out of order: %q, %q", a, b)
+	}
+}
@@ -0,0 +1,142 @@
+package netio
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrNotAcceptable is returned by Respond when none of the registered codecs
+// match the request's Accept header. A 406 response has already been
+// written when it is returned.
+var ErrNotAcceptable = errors.New("no acceptable media type")
+
+// Respond writes data using the media type that best matches the request's
+// Accept header. It behaves like netio.Write but supports every codec
+// registered with RegisterCodec (application/json, application/xml, text/csv
+// and application/x-ndjson by default).
+//
+// The Accept header is parsed with q-values and wildcards. When several
+// media types are equally acceptable, the first one listed by the client
+// wins. A missing Accept header selects application/json, which is written
+// with netio.Write and therefore follows the output mode. The response
+// always carries "Vary: Accept" so caches keep the representations apart.
+//
+// If nothing matches, a 406 Not Acceptable response is written through
+// netio.Error and ErrNotAcceptable is returned. If the data cannot be encoded
+// nothing is written and ErrNetioMarshalFailure is returned.
+//
+// Parameters:
+//   - w: The http.ResponseWriter to write the response to
+//   - r: The *http.Request whose Accept header is inspected
+//   - status: HTTP status code to send
+//   - data: The Envelope containing response data to be encoded
+//   - headers: Additional HTTP headers to include in the response
+//
+// Example:
+//
+//	// curl -H "Accept: text/csv" localhost:8080/users
+//	env := netio.Envelope{"users": users}
+//	if err := netio.Respond(w, r, http.StatusOK, env, nil); err != nil {
+//	    // handle error
+//	}
+func Respond(w http.ResponseWriter, r *http.Request, status int, data Envelope, headers http.Header) error {
+	w.Header().Add("Vary", "Accept")
+
+	c, ok := negotiate(r.Header.Get("Accept"), registeredCodecs())
+	if !ok {
+		Error(w, "error", http.StatusNotAcceptable, nil)
+		return ErrNotAcceptable
+	}
+
+	// the built-in JSON codec honours the output mode, see SetOutputMode
+	if c.builtin && c.mediaType == "application/json" {
+		return Write(w, status, data, headers, WithRequest(r))
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	if err := c.encode(buf, data); err != nil {
+		return ErrNetioMarshalFailure
+	}
+
+	return writeRaw(w, status, c.mediaType, buf.Bytes(), headers)
+}
+
+// mediaRange is a single entry of an Accept header.
+type mediaRange struct {
+	typ, subtype string
+	q            float64
+}
+
+// parseAccept parses an Accept header into media ranges, in header order.
+// Malformed entries are skipped.
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		typ, subtype, ok := strings.Cut(mediaType, "/")
+		if !ok {
+			continue
+		}
+
+		q := 1.0
+		if qs, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(qs, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{typ, subtype, q})
+	}
+	return ranges
+}
+
+// negotiate picks the codec that best matches accept. Each codec is given
+// the q-value of the most specific range matching it; the highest q-value
+// wins, then the range listed first by the client, then registration order.
+func negotiate(accept string, available []codec) (codec, bool) {
+	if len(available) == 0 {
+		return codec{}, false
+	}
+	if strings.TrimSpace(accept) == "" {
+		return available[0], true
+	}
+
+	ranges := parseAccept(accept)
+
+	var best codec
+	bestQ, bestPos := 0.0, len(ranges)
+	for _, c := range available {
+		typ, subtype, _ := strings.Cut(c.mediaType, "/")
+
+		q, pos, specificity := 0.0, len(ranges), 0
+		for i, mr := range ranges {
+			var s int
+			switch {
+			case mr.typ == typ && mr.subtype == subtype:
+				s = 3
+			case mr.typ == typ && mr.subtype == "*":
+				s = 2
+			case mr.typ == "*" && mr.subtype == "*":
+				s = 1
+			default:
+				continue
+			}
+			if s > specificity {
+				q, pos, specificity = mr.q, i, s
+			}
+		}
+
+		if q > bestQ || (q == bestQ && q > 0 && pos < bestPos) {
+			best, bestQ, bestPos = c, q, pos
+		}
+	}
+
+	return best, bestQ > 0
+}
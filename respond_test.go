@@ -0,0 +1,130 @@
+package netio
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRespond(t *testing.T) {
+	type user struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	data := Envelope{"users": []user{{1, "Jack"}, {2, "Jill"}}}
+
+	tests := []struct {
+		name       string
+		accept     string
+		wantStatus int
+		wantType   string
+		wantBody   string
+	}{
+		{"no accept header", "", http.StatusOK, "application/json", `"users"`},
+		{"wildcard", "*/*", http.StatusOK, "application/json", `"users"`},
+		{"xml", "application/xml", http.StatusOK, "application/xml", "<name>Jack</name>"},
+		{"csv", "text/csv", http.StatusOK, "text/csv", "id,name\n1,Jack\n2,Jill\n"},
+		{"ndjson", "application/x-ndjson", http.StatusOK, "application/x-ndjson", "{\"id\":1,\"name\":\"Jack\"}\n{\"id\":2"},
+		{"q-values", "application/json;q=0.5, text/csv;q=0.9", http.StatusOK, "text/csv", "id,name"},
+		{"type wildcard", "text/*", http.StatusOK, "text/csv", "id,name"},
+		{"excluded by q=0", "application/json;q=0, */*;q=0.1", http.StatusOK, "application/xml", "<response>"},
+		{"not acceptable", "image/png", http.StatusNotAcceptable, "application/json", `"status": 406`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.accept != "" {
+				r.Header.Set("Accept", test.accept)
+			}
+			w := httptest.NewRecorder()
+
+			Respond(w, r, http.StatusOK, data, nil)
+
+			res := w.Result()
+			if res.StatusCode != test.wantStatus {
+				t.Errorf("Respond() code = %v, want %v", res.StatusCode, test.wantStatus)
+			}
+			if ct := res.Header.Get("Content-Type"); ct != test.wantType {
+				t.Errorf("Respond() Content-Type = %q, want %q", ct, test.wantType)
+			}
+			if res.Header.Get("Vary") != "Accept" {
+				t.Error("Respond() did not set Vary: Accept")
+			}
+			if !strings.Contains(w.Body.String(), test.wantBody) {
+				t.Errorf("Respond() body = %q, want it to contain %q", w.Body.String(), test.wantBody)
+			}
+		})
+	}
+}
+
+func TestRegisterCodec(t *testing.T) {
+	RegisterCodec("application/vnd.netio.test", func(w io.Writer, data Envelope) error {
+		_, err := io.WriteString(w, "custom")
+		return err
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", "application/vnd.netio.test")
+	w := httptest.NewRecorder()
+
+	if err := Respond(w, r, http.StatusOK, Envelope{"a": 1}, nil); err != nil {
+		t.Fatalf("Respond() error = %v", err)
+	}
+	if w.Body.String() != "custom" {
+		t.Errorf("Respond() body = %q, want custom codec output", w.Body.String())
+	}
+}
+
+func TestRespond_EncodeFailure(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", "text/csv")
+	w := httptest.NewRecorder()
+
+	// csv requires a single list
+	err := Respond(w, r, http.StatusOK, Envelope{"a": 1, "b": 2}, nil)
+	if err != ErrNetioMarshalFailure {
+		t.Errorf("Respond() error = %v, want ErrNetioMarshalFailure", err)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("Respond() wrote body %q after encode failure", w.Body.String())
+	}
+}
+
+func TestEncodeXML_InvalidNames(t *testing.T) {
+	data := Envelope{"scores": map[string]int{"1st place": 3, "a<b": 2, "x:y": 4, "valid": 1}}
+
+	var buf bytes.Buffer
+	if err := encodeXML(&buf, data); err != nil {
+		t.Fatalf("encodeXML() error = %v", err)
+	}
+
+	type entry struct {
+		XMLName xml.Name
+		Key     string `xml:"key,attr"`
+		Value   string `xml:",chardata"`
+	}
+	var doc struct {
+		Scores struct {
+			Entries []entry `xml:",any"`
+		} `xml:"scores"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("xml.Unmarshal() error = %v\n%s", err, buf.String())
+	}
+
+	want := []entry{
+		{xml.Name{Local: "entry"}, "1st place", "3"},
+		{xml.Name{Local: "entry"}, "a<b", "2"},
+		{xml.Name{Local: "valid"}, "", "1"},
+		{xml.Name{Local: "entry"}, "x:y", "4"},
+	}
+	if !slices.Equal(doc.Scores.Entries, want) {
+		t.Errorf("encodeXML() entries = %+v, want %+v", doc.Scores.Entries, want)
+	}
+}
@@ -0,0 +1,235 @@
+package netio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EventStreamContentType is the content type of Server-Sent Events responses.
+const EventStreamContentType = "text/event-stream"
+
+// DefaultHeartbeat is the interval between heartbeat comments sent by an
+// SSEWriter when no WithHeartbeat option is given.
+const DefaultHeartbeat = 15 * time.Second
+
+// ErrStreamClosed is returned by SSEWriter methods called after Close.
+var ErrStreamClosed = errors.New("stream is closed")
+
+// ErrInvalidEventField is returned by SSEWriter.Send when the event name or
+// id contains a line break, which would corrupt the stream.
+var ErrInvalidEventField = errors.New("event name and id must not contain line breaks")
+
+// SSEWriter writes a Server-Sent Events (text/event-stream) response. It is
+// created with NewSSEWriter and is safe for concurrent use.
+type SSEWriter struct {
+	mu          sync.Mutex
+	w           http.ResponseWriter
+	rc          *http.ResponseController
+	ctx         context.Context
+	lastEventID string
+	// err is the first write or context error, after which the stream is
+	// unusable
+	err error
+
+	stop    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+}
+
+// SSEOption configures an SSEWriter created by NewSSEWriter.
+type SSEOption func(*sseConfig)
+
+// sseConfig holds the settings of an SSEWriter.
+type sseConfig struct {
+	heartbeat time.Duration
+}
+
+// WithHeartbeat sets the interval between heartbeat comments, which keep
+// proxies from closing idle connections. Values less than or equal to zero
+// disable heartbeats.
+func WithHeartbeat(d time.Duration) SSEOption {
+	return func(c *sseConfig) {
+		c.heartbeat = d
+	}
+}
+
+// NewSSEWriter starts a Server-Sent Events response on w. The status 200,
+// the security headers set by Write, Cache-Control: no-cache and the given
+// headers are sent and flushed immediately.
+//
+// The writer stops once the request context is cancelled, after which every
+// method returns the context error. Heartbeat comments are sent every
+// DefaultHeartbeat unless configured otherwise with WithHeartbeat. Close
+// must be called before the handler returns.
+//
+// Example:
+//
+//	func (app *application) statusHandler(w http.ResponseWriter, r *http.Request) {
+//	    sse := netio.NewSSEWriter(w, r, nil)
+//	    defer sse.Close()
+//
+//	    updates := app.status.Subscribe(r.Context(), sse.LastEventID())
+//	    for u := range updates {
+//	        if err := sse.Send("status", u.ID, u); err != nil {
+//	            return // client went away
+//	        }
+//	    }
+//	}
+func NewSSEWriter(w http.ResponseWriter, r *http.Request, headers http.Header, opts ...SSEOption) *SSEWriter {
+	cfg := sseConfig{heartbeat: DefaultHeartbeat}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	s := &SSEWriter{
+		w:           w,
+		rc:          http.NewResponseController(w),
+		ctx:         r.Context(),
+		lastEventID: r.Header.Get("Last-Event-ID"),
+		stop:        make(chan struct{}),
+		stopped:     make(chan struct{}),
+	}
+
+	setDefaultHeaders(w.Header(), EventStreamContentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	// stop reverse proxies such as nginx from buffering events
+	w.Header().Set("X-Accel-Buffering", "no")
+	for key, values := range headers {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(http.StatusOK)
+
+	s.mu.Lock()
+	s.flush()
+	s.mu.Unlock()
+
+	go s.run(cfg.heartbeat)
+	return s
+}
+
+// run sends heartbeats until the writer is closed or the request context
+// is cancelled.
+func (s *SSEWriter) run(heartbeat time.Duration) {
+	defer close(s.stopped)
+
+	var tick <-chan time.Time
+	if heartbeat > 0 {
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-s.ctx.Done():
+			s.mu.Lock()
+			if s.err == nil {
+				s.err = s.ctx.Err()
+			}
+			s.mu.Unlock()
+			return
+		case <-tick:
+			s.write(": heartbeat\n\n")
+		}
+	}
+}
+
+// LastEventID returns the Last-Event-ID header sent by a reconnecting
+// client, so the stream can resume after the last event it received.
+// It is empty for new connections.
+func (s *SSEWriter) LastEventID() string {
+	return s.lastEventID
+}
+
+// Send writes an event with the given name and id, holding data encoded as
+// JSON, and flushes it to the client. An empty event name or id is left out
+// of the event; clients treat events without a name as "message".
+func (s *SSEWriter) Send(event, id string, data any) error {
+	if strings.ContainsAny(event, "\r\n") || strings.ContainsAny(id, "\r\n") {
+		return ErrInvalidEventField
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrNetioMarshalFailure, err)
+	}
+
+	var sb strings.Builder
+	if event != "" {
+		sb.WriteString("event: " + event + "\n")
+	}
+	if id != "" {
+		sb.WriteString("id: " + id + "\n")
+	}
+	// compact JSON never contains line breaks, so one data line is enough
+	sb.WriteString("data: ")
+	sb.Write(b)
+	sb.WriteString("\n\n")
+
+	return s.write(sb.String())
+}
+
+// Retry tells the client how long to wait before reconnecting after the
+// connection is lost.
+func (s *SSEWriter) Retry(d time.Duration) error {
+	return s.write("retry: " + strconv.FormatInt(d.Milliseconds(), 10) + "\n\n")
+}
+
+// Err returns the sticky error that stopped the stream, if any. It is
+// ErrStreamClosed after Close unless the stream had failed before.
+func (s *SSEWriter) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close stops the heartbeat and waits until nothing else is written, after
+// which the handler may return. It is safe to call Close more than once.
+func (s *SSEWriter) Close() error {
+	s.once.Do(func() {
+		s.mu.Lock()
+		if s.err == nil {
+			s.err = ErrStreamClosed
+		}
+		s.mu.Unlock()
+		close(s.stop)
+	})
+	<-s.stopped
+	return nil
+}
+
+// write sends msg and flushes it unless the stream has stopped.
+func (s *SSEWriter) write(msg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+	if err := s.ctx.Err(); err != nil {
+		s.err = err
+		return err
+	}
+	if _, err := s.w.Write([]byte(msg)); err != nil {
+		s.err = err
+		return err
+	}
+	return s.flush()
+}
+
+// flush sends buffered events to the client. Callers must hold s.mu.
+func (s *SSEWriter) flush() error {
+	if err := s.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		s.err = err
+		return err
+	}
+	return nil
+}
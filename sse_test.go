@@ -0,0 +1,103 @@
+package netio
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSSEWriter(t *testing.T) {
+	t.Run("events", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/status", nil)
+		r.Header.Set("Last-Event-ID", "41")
+
+		sse := NewSSEWriter(w, r, nil, WithHeartbeat(0))
+		if got := sse.LastEventID(); got != "41" {
+			t.Errorf("LastEventID() = %q, want 41", got)
+		}
+		if err := sse.Retry(3 * time.Second); err != nil {
+			t.Fatalf("Retry() error = %v", err)
+		}
+		if err := sse.Send("status", "42", map[string]string{"state": "ready"}); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+		if err := sse.Send("", "", 1); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+		sse.Close()
+
+		want := "retry: 3000\n\n" +
+			"event: status\nid: 42\ndata: {\"state\":\"ready\"}\n\n" +
+			"data: 1\n\n"
+		if got := w.Body.String(); got != want {
+			t.Errorf("body = %q, want %q", got, want)
+		}
+		if !w.Flushed {
+			t.Error("Send() did not flush the response")
+		}
+
+		wantHeaders := map[string]string{
+			"Content-Type":           EventStreamContentType,
+			"Cache-Control":          "no-cache",
+			"X-Content-Type-Options": "nosniff",
+			"X-Frame-Options":        "DENY",
+		}
+		for key, value := range wantHeaders {
+			if got := w.Result().Header.Get(key); got != value {
+				t.Errorf("header %s = %q, want %q", key, got, value)
+			}
+		}
+
+		if err := sse.Send("status", "43", nil); !errors.Is(err, ErrStreamClosed) {
+			t.Errorf("Send() after Close error = %v, want ErrStreamClosed", err)
+		}
+	})
+
+	t.Run("invalid fields", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		sse := NewSSEWriter(w, httptest.NewRequest(http.MethodGet, "/", nil), nil, WithHeartbeat(0))
+		defer sse.Close()
+
+		if err := sse.Send("status\ndata: injected", "", nil); !errors.Is(err, ErrInvalidEventField) {
+			t.Errorf("Send() error = %v, want ErrInvalidEventField", err)
+		}
+		if err := sse.Send("", "", func() {}); !errors.Is(err, ErrNetioMarshalFailure) {
+			t.Errorf("Send() error = %v, want ErrNetioMarshalFailure", err)
+		}
+		if err := sse.Err(); err != nil {
+			t.Errorf("Err() = %v, want the stream to stay usable", err)
+		}
+	})
+
+	t.Run("heartbeat", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		sse := NewSSEWriter(w, httptest.NewRequest(http.MethodGet, "/", nil), nil, WithHeartbeat(time.Millisecond))
+		time.Sleep(20 * time.Millisecond)
+		sse.Close()
+
+		if !strings.Contains(w.Body.String(), ": heartbeat\n\n") {
+			t.Errorf("body = %q, want heartbeat comments", w.Body.String())
+		}
+	})
+
+	t.Run("context cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		r := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+		w := httptest.NewRecorder()
+		sse := NewSSEWriter(w, r, nil)
+		defer sse.Close()
+
+		cancel()
+		if err := sse.Send("status", "", 1); !errors.Is(err, context.Canceled) {
+			t.Errorf("Send() error = %v, want context.Canceled", err)
+		}
+		if w.Body.Len() != 0 {
+			t.Errorf("body = %q, want nothing written after cancellation", w.Body.String())
+		}
+	})
+}
@@ -0,0 +1,134 @@
+package netio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// NDJSONContentType is the content type of newline delimited JSON responses.
+const NDJSONContentType = "application/x-ndjson"
+
+// StreamWriter writes a newline delimited JSON (NDJSON / JSON Lines)
+// response one value at a time, so large results never have to be held in
+// memory. It is created with NewStreamWriter and is not safe for concurrent
+// use.
+type StreamWriter struct {
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	ctx     context.Context
+	status  int
+	headers http.Header
+	started bool
+	// err is the first write or context error, after which the stream is
+	// unusable
+	err error
+}
+
+// NewStreamWriter returns a StreamWriter writing an application/x-ndjson
+// response to w. The status, the security headers set by Write and the
+// given headers are sent with the first call to Encode or Flush, so a
+// handler can still write a regular error response if it fails before
+// producing any value. r is used to stop writing when the client
+// disconnects.
+//
+// Values are buffered by the underlying ResponseWriter; call Flush to push
+// them to the client, e.g. every few hundred rows.
+//
+// Example:
+//
+//	sw := netio.NewStreamWriter(w, r, http.StatusOK, nil)
+//	for rows.Next() {
+//	    ...
+//	    if err := sw.Encode(row); err != nil {
+//	        return // client went away or the connection failed
+//	    }
+//	    if n++; n%500 == 0 {
+//	        sw.Flush()
+//	    }
+//	}
+//	sw.Flush()
+func NewStreamWriter(w http.ResponseWriter, r *http.Request, status int, headers http.Header) *StreamWriter {
+	return &StreamWriter{
+		w:       w,
+		rc:      http.NewResponseController(w),
+		ctx:     r.Context(),
+		status:  status,
+		headers: headers,
+	}
+}
+
+// start sends the status and headers once.
+func (sw *StreamWriter) start() {
+	if sw.started {
+		return
+	}
+	sw.started = true
+
+	setDefaultHeaders(sw.w.Header(), NDJSONContentType)
+	for key, values := range sw.headers {
+		sw.w.Header()[key] = values
+	}
+	sw.w.WriteHeader(sw.status)
+}
+
+// Encode writes v as a single line of JSON.
+//
+// A value that cannot be marshalled is skipped and reported with an error
+// wrapping ErrNetioMarshalFailure; the stream stays usable. Any other error,
+// including the request context being cancelled, is sticky: it is returned
+// by every later call to Encode, Flush and Err.
+func (sw *StreamWriter) Encode(v any) error {
+	if sw.err != nil {
+		return sw.err
+	}
+	if err := sw.ctx.Err(); err != nil {
+		sw.err = err
+		return err
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		// nothing has been written, the stream is still consistent
+		return fmt.Errorf("%w: %w", ErrNetioMarshalFailure, err)
+	}
+	b = append(b, '\n')
+
+	sw.start()
+
+	n, err := sw.w.Write(b)
+	if err == nil && n < len(b) {
+		err = io.ErrShortWrite
+	}
+	if err != nil {
+		sw.err = err
+		return err
+	}
+	return nil
+}
+
+// Flush sends buffered lines to the client. ResponseWriters that cannot
+// flush are not treated as an error.
+func (sw *StreamWriter) Flush() error {
+	if sw.err != nil {
+		return sw.err
+	}
+	if err := sw.ctx.Err(); err != nil {
+		sw.err = err
+		return err
+	}
+	sw.start()
+
+	if err := sw.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		sw.err = err
+		return err
+	}
+	return nil
+}
+
+// Err returns the sticky error that stopped the stream, if any.
+func (sw *StreamWriter) Err() error {
+	return sw.err
+}
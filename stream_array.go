@@ -0,0 +1,177 @@
+package netio
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// StreamErrorTrailer is the HTTP trailer StreamArray sets when the sequence
+// fails after the response has started. Its value is the status code and
+// client-facing message of the error, e.g. "500 Internal Server Error".
+const StreamErrorTrailer = "X-Stream-Error"
+
+// StreamArray writes a JSON object holding the values of seq under key,
+// followed by their count, producing the body incrementally so the list
+// never has to be held in memory:
+//
+//	{"items":[...],"count":2}
+//
+// Formatting follows the package output mode (see SetOutputMode), with r
+// used for OutputAuto. The security headers set by Write and the given
+// headers are sent once seq yields its first value.
+//
+// If seq fails before yielding any value, a regular error response is
+// written instead, as WriteErr would. If it fails later, the array is
+// closed and an "error" member holding an ErrorResponse is appended, and
+// the StreamErrorTrailer trailer is set, so clients can tell a truncated
+// list from a complete one. Values that cannot be marshalled are handled
+// the same way. Streaming stops when the request context is cancelled.
+//
+// StreamArray returns the error from seq, the marshalling, write or
+// context error that stopped the stream, or nil.
+//
+// Example:
+//
+//	func (s *Store) Orders(ctx context.Context) iter.Seq2[Order, error] { ... }
+//
+//	err := netio.StreamArray(w, r, http.StatusOK, "orders", store.Orders(r.Context()), nil)
+//
+// The response for a query that fails after two rows:
+//
+//	{
+//	    "orders": [
+//	        {"id": 1},
+//	        {"id": 2}
+//	    ],
+//	    "count": 2,
+//	    "error": {
+//	        "status": 500,
+//	        "message": "Internal Server Error",
+//	        "timestamp": "2024-01-09T12:00:00Z"
+//	    }
+//	}
+func StreamArray[T any](w http.ResponseWriter, r *http.Request, status int, key string, seq iter.Seq2[T, error], headers http.Header) error {
+	pretty := writeConfig{mode: outputMode, r: r}.pretty()
+	ctx := r.Context()
+
+	var bw *bufio.Writer
+	// newline writes a line break followed by depth levels of indentation
+	newline := func(depth int) {
+		if pretty {
+			bw.WriteByte('\n')
+			bw.WriteString(strings.Repeat("\t", depth))
+		}
+	}
+	marshal := func(v any, prefix string) ([]byte, error) {
+		if pretty {
+			return json.MarshalIndent(v, prefix, "\t")
+		}
+		return json.Marshal(v)
+	}
+	member := func(name string) {
+		newline(1)
+		k, _ := json.Marshal(name)
+		bw.Write(k)
+		bw.WriteByte(':')
+		if pretty {
+			bw.WriteByte(' ')
+		}
+	}
+
+	start := func() {
+		setDefaultHeaders(w.Header(), "application/json")
+		for key, values := range headers {
+			w.Header()[key] = values
+		}
+		w.Header().Add("Trailer", StreamErrorTrailer)
+		w.WriteHeader(status)
+
+		bw = bufio.NewWriter(w)
+		bw.WriteByte('{')
+		member(key)
+		bw.WriteByte('[')
+	}
+
+	count := 0
+	var streamErr error
+	// aborted is set when the client went away or the connection failed
+	aborted := false
+	for v, err := range seq {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			streamErr, aborted = ctxErr, true
+			break
+		}
+		if err != nil {
+			streamErr = err
+			break
+		}
+
+		b, err := marshal(v, "\t\t")
+		if err != nil {
+			streamErr = fmt.Errorf("%w: %w", ErrNetioMarshalFailure, err)
+			break
+		}
+
+		if bw == nil {
+			start()
+		} else {
+			bw.WriteByte(',')
+		}
+		newline(2)
+		if _, err := bw.Write(b); err != nil {
+			// the client cannot receive anything else
+			streamErr, aborted = err, true
+			break
+		}
+		count++
+	}
+
+	if bw == nil {
+		if streamErr != nil {
+			if !aborted {
+				writeErr(w, r, streamErr)
+			}
+			return streamErr
+		}
+		// empty sequence
+		start()
+	}
+
+	if count > 0 {
+		newline(1)
+	}
+	bw.WriteByte(']')
+	bw.WriteByte(',')
+	member("count")
+	bw.WriteString(strconv.Itoa(count))
+
+	if streamErr != nil && !aborted {
+		code, opts := errorOptions(r, streamErr)
+		if code < 100 || code > 599 {
+			code = http.StatusInternalServerError
+		}
+		res := BuildErrorWithOptions(code, opts)
+
+		bw.WriteByte(',')
+		member("error")
+		b, err := marshal(res, "\t")
+		if err != nil {
+			b, _ = marshal(ErrorFallback()["error"], "\t")
+		}
+		bw.Write(b)
+		w.Header().Set(StreamErrorTrailer, strconv.Itoa(code)+" "+res.Message)
+		logError(code, opts, nil)
+	}
+
+	newline(0)
+	bw.WriteString("}\n")
+	if err := bw.Flush(); err != nil && streamErr == nil {
+		streamErr = err
+	}
+	return streamErr
+}
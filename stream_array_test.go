@@ -0,0 +1,135 @@
+package netio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// seqOf yields values followed by err, if not nil.
+func seqOf[T any](values []T, err error) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for _, v := range values {
+			if !yield(v, nil) {
+				return
+			}
+		}
+		if err != nil {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
+
+func TestStreamArray(t *testing.T) {
+	type item struct {
+		ID int `json:"id"`
+	}
+	errQuery := errors.New("replica 3 unreachable")
+
+	tests := []struct {
+		name        string
+		mode        OutputMode
+		seq         iter.Seq2[item, error]
+		wantStatus  int
+		wantBody    string
+		wantTrailer string
+	}{
+		{
+			name:       "compact",
+			mode:       OutputCompact,
+			seq:        seqOf([]item{{1}, {2}}, nil),
+			wantStatus: http.StatusOK,
+			wantBody:   `{"items":[{"id":1},{"id":2}],"count":2}` + "\n",
+		},
+		{
+			name:       "pretty matches Write",
+			mode:       OutputPretty,
+			seq:        seqOf([]item{{1}, {2}}, nil),
+			wantStatus: http.StatusOK,
+			wantBody:   "{\n\t\"items\": [\n\t\t{\n\t\t\t\"id\": 1\n\t\t},\n\t\t{\n\t\t\t\"id\": 2\n\t\t}\n\t],\n\t\"count\": 2\n}\n",
+		},
+		{
+			name:       "empty",
+			mode:       OutputCompact,
+			seq:        seqOf[item](nil, nil),
+			wantStatus: http.StatusOK,
+			wantBody:   `{"items":[],"count":0}` + "\n",
+		},
+		{
+			name:        "mid stream error",
+			mode:        OutputCompact,
+			seq:         seqOf([]item{{1}}, errQuery),
+			wantStatus:  http.StatusOK,
+			wantBody:    `{"items":[{"id":1}],"count":1,"error":{"status":500,"message":"Internal Server Error"}}` + "\n",
+			wantTrailer: "500 Internal Server Error",
+		},
+		{
+			name:       "error before first value",
+			mode:       OutputCompact,
+			seq:        seqOf[item](nil, NewHTTPError(http.StatusForbidden, "", "export not allowed")),
+			wantStatus: http.StatusForbidden,
+			wantBody:   `{"error":{"status":403,"message":"export not allowed"}}` + "\n",
+		},
+	}
+
+	SetTimestampFormat(TimestampOmit)
+	defer SetTimestampFormat(TimestampDefault)
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			SetOutputMode(tc.mode)
+			defer SetOutputMode(OutputPretty)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/export", nil)
+			StreamArray(w, r, http.StatusOK, "items", tc.seq, nil)
+
+			res := w.Result()
+			if res.StatusCode != tc.wantStatus {
+				t.Errorf("StreamArray() status = %v, want %v", res.StatusCode, tc.wantStatus)
+			}
+			if got := w.Body.String(); got != tc.wantBody {
+				t.Errorf("StreamArray() body = %q, want %q", got, tc.wantBody)
+			}
+			if got := res.Trailer.Get(StreamErrorTrailer); got != tc.wantTrailer {
+				t.Errorf("StreamArray() trailer = %q, want %q", got, tc.wantTrailer)
+			}
+			if !json.Valid(w.Body.Bytes()) {
+				t.Error("StreamArray() wrote invalid JSON")
+			}
+		})
+	}
+
+	t.Run("returns error", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if err := StreamArray(w, r, http.StatusOK, "items", seqOf([]int{1}, errQuery), nil); !errors.Is(err, errQuery) {
+			t.Errorf("StreamArray() error = %v, want %v", err, errQuery)
+		}
+	})
+
+	t.Run("client disconnect", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		r := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+		w := httptest.NewRecorder()
+
+		seq := func(yield func(int, error) bool) {
+			for i := 0; ; i++ {
+				if i == 2 {
+					cancel()
+				}
+				if !yield(i, nil) {
+					return
+				}
+			}
+		}
+		if err := StreamArray(w, r, http.StatusOK, "items", seq, nil); !errors.Is(err, context.Canceled) {
+			t.Errorf("StreamArray() error = %v, want context.Canceled", err)
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package netio
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStreamWriter(t *testing.T) {
+	t.Run("lines", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/export", nil)
+		sw := NewStreamWriter(w, r, http.StatusOK, http.Header{"X-Total": []string{"2"}})
+
+		for _, row := range []map[string]int{{"id": 1}, {"id": 2}} {
+			if err := sw.Encode(row); err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+		}
+		if err := sw.Flush(); err != nil {
+			t.Fatalf("Flush() error = %v", err)
+		}
+
+		if !w.Flushed {
+			t.Error("Flush() did not flush the response")
+		}
+		if got, want := w.Body.String(), "{\"id\":1}\n{\"id\":2}\n"; got != want {
+			t.Errorf("body = %q, want %q", got, want)
+		}
+		wantHeaders := map[string]string{
+			"Content-Type":           NDJSONContentType,
+			"X-Content-Type-Options": "nosniff",
+			"X-Frame-Options":        "DENY",
+			"X-Total":                "2",
+		}
+		for key, value := range wantHeaders {
+			if got := w.Result().Header.Get(key); got != value {
+				t.Errorf("header %s = %q, want %q", key, got, value)
+			}
+		}
+	})
+
+	t.Run("marshal failure", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		sw := NewStreamWriter(w, httptest.NewRequest(http.MethodGet, "/", nil), http.StatusCreated, nil)
+
+		if err := sw.Encode(func() {}); !errors.Is(err, ErrNetioMarshalFailure) {
+			t.Errorf("Encode() error = %v, want ErrNetioMarshalFailure", err)
+		}
+		if w.Body.Len() != 0 || w.Result().Header.Get("Content-Type") != "" {
+			t.Error("Encode() started the response for a value it could not marshal")
+		}
+		if err := sw.Encode(1); err != nil {
+			t.Errorf("Encode() after marshal failure error = %v", err)
+		}
+		if w.Code != http.StatusCreated || w.Body.String() != "1\n" {
+			t.Errorf("response = %d %q, want 201 \"1\\n\"", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("client disconnect", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		r := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+		w := httptest.NewRecorder()
+		sw := NewStreamWriter(w, r, http.StatusOK, nil)
+
+		sw.Encode(1)
+		cancel()
+		if err := sw.Encode(2); !errors.Is(err, context.Canceled) {
+			t.Errorf("Encode() error = %v, want context.Canceled", err)
+		}
+		if err := sw.Flush(); !errors.Is(err, context.Canceled) {
+			t.Errorf("Flush() error = %v, want sticky context.Canceled", err)
+		}
+		if w.Body.String() != "1\n" {
+			t.Errorf("body = %q, want only the value written before cancellation", w.Body.String())
+		}
+	})
+
+	t.Run("short write", func(t *testing.T) {
+		w := &shortWriter{ResponseRecorder: httptest.NewRecorder(), limit: 2}
+		sw := NewStreamWriter(w, httptest.NewRequest(http.MethodGet, "/", nil), http.StatusOK, nil)
+
+		if err := sw.Encode("value"); !errors.Is(err, io.ErrShortWrite) {
+			t.Errorf("Encode() error = %v, want io.ErrShortWrite", err)
+		}
+		if !errors.Is(sw.Err(), io.ErrShortWrite) {
+			t.Errorf("Err() = %v, want io.ErrShortWrite", sw.Err())
+		}
+	})
+}
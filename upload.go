@@ -0,0 +1,416 @@
+package netio
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	// DefaultMaxFileBytes is the size limit of a single uploaded file when
+	// FileOptions.MaxFileBytes is not set (10MB).
+	DefaultMaxFileBytes int64 = 10 << 20
+	// DefaultMaxUploadBytes is the size limit of the whole multipart body
+	// when FileOptions.MaxTotalBytes is not set (32MB).
+	DefaultMaxUploadBytes int64 = 32 << 20
+	// DefaultMaxFiles is the number of files accepted when
+	// FileOptions.MaxFiles is not set.
+	DefaultMaxFiles = 10
+)
+
+// sniffLen is the number of bytes http.DetectContentType looks at.
+const sniffLen = 512
+
+// UploadErrorKind classifies why an upload was rejected.
+type UploadErrorKind int
+
+const (
+	// UploadContentType means the request is not multipart/form-data.
+	UploadContentType UploadErrorKind = iota + 1
+	// UploadMalformed means the multipart body could not be parsed.
+	UploadMalformed
+	// UploadTooLarge means the body exceeded FileOptions.MaxTotalBytes.
+	UploadTooLarge
+	// UploadFileTooLarge means a file exceeded FileOptions.MaxFileBytes.
+	UploadFileTooLarge
+	// UploadTooManyFiles means more than FileOptions.MaxFiles files were sent.
+	UploadTooManyFiles
+	// UploadTypeNotAllowed means the sniffed type of a file is not in
+	// FileOptions.AllowedTypes.
+	UploadTypeNotAllowed
+)
+
+// String returns a short lowercase name for the kind, e.g. "too_large".
+func (k UploadErrorKind) String() string {
+	switch k {
+	case UploadContentType:
+		return "content_type"
+	case UploadMalformed:
+		return "malformed"
+	case UploadTooLarge:
+		return "too_large"
+	case UploadFileTooLarge:
+		return "file_too_large"
+	case UploadTooManyFiles:
+		return "too_many_files"
+	case UploadTypeNotAllowed:
+		return "type_not_allowed"
+	default:
+		return "unknown"
+	}
+}
+
+// UploadError is returned by ReadFiles when an upload is rejected. Like
+// ReadError it provides a client-safe message, implements HTTPError and adds
+// its message to the validation map when passed to WriteErr, keyed by the
+// form field of the offending file or "body".
+type UploadError struct {
+	// Kind classifies the failure.
+	Kind UploadErrorKind
+	// Field is the form field of the offending file, when known.
+	Field string
+	// Filename is the client-supplied name of the offending file, when known.
+	Filename string
+	// Limit is the limit that was exceeded, for size and count errors.
+	Limit int64
+	// ContentType is the sniffed type of a file that was not allowed.
+	ContentType string
+	// Err is the underlying error, if any.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *UploadError) Error() string {
+	msg := "netio.ReadFiles(): " + e.Message()
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error.
+func (e *UploadError) Unwrap() error {
+	return e.Err
+}
+
+// Message returns a description of the error that is safe to show to API
+// clients.
+func (e *UploadError) Message() string {
+	switch e.Kind {
+	case UploadContentType:
+		return "body must be multipart/form-data"
+	case UploadMalformed:
+		return "body contains a badly-formed multipart form"
+	case UploadTooLarge:
+		return fmt.Sprintf("body must not be larger than %d bytes", e.Limit)
+	case UploadFileTooLarge:
+		return fmt.Sprintf("file %q must not be larger than %d bytes", e.Filename, e.Limit)
+	case UploadTooManyFiles:
+		return fmt.Sprintf("must not upload more than %d files", e.Limit)
+	case UploadTypeNotAllowed:
+		return fmt.Sprintf("file %q has a type that is not allowed (%s)", e.Filename, e.ContentType)
+	default:
+		return "upload could not be read"
+	}
+}
+
+// StatusCode returns the HTTP status code that best describes the error:
+//   - 413 Request Entity Too Large for UploadTooLarge and UploadFileTooLarge
+//   - 415 Unsupported Media Type for UploadContentType and UploadTypeNotAllowed
+//   - 400 Bad Request for everything else
+func (e *UploadError) StatusCode() int {
+	switch e.Kind {
+	case UploadTooLarge, UploadFileTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case UploadContentType, UploadTypeNotAllowed:
+		return http.StatusUnsupportedMediaType
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// validator returns a Validator holding the client message keyed by the
+// form field of the offending file, or by "body".
+func (e *UploadError) validator() *Validator {
+	key := e.Field
+	if key == "" {
+		key = "body"
+	}
+	v := NewValidator()
+	v.AddError(key, e.Message())
+	return v
+}
+
+// UploadedFile describes a file received by ReadFiles.
+type UploadedFile struct {
+	// Field is the form field the file was sent in.
+	Field string
+	// Filename is the client-supplied file name, without any directory.
+	// It must not be trusted as a path.
+	Filename string
+	// ContentType is the type detected with http.DetectContentType. The
+	// type claimed by the client is never used.
+	ContentType string
+	// Size is the number of bytes written to the sink.
+	Size int64
+	// Path is the temporary file holding the contents when no
+	// FileOptions.Sink was given. The caller is responsible for removing it.
+	Path string
+}
+
+// Upload is the result of ReadFiles.
+type Upload struct {
+	// Files holds the uploaded files in the order they were received.
+	Files []UploadedFile
+	// Values holds the form fields that are not files.
+	Values url.Values
+}
+
+// FileOptions configures ReadFiles. All fields are optional.
+type FileOptions struct {
+	// MaxFileBytes limits the size of each file (defaults to DefaultMaxFileBytes)
+	MaxFileBytes int64
+	// MaxTotalBytes limits the size of the whole body (defaults to DefaultMaxUploadBytes)
+	MaxTotalBytes int64
+	// MaxFiles limits the number of files (defaults to DefaultMaxFiles)
+	MaxFiles int
+	// AllowedTypes lists the accepted media types as detected by
+	// http.DetectContentType, e.g. "image/png" or "image/*". Any type is
+	// accepted when empty.
+	AllowedTypes []string
+	// Sink opens the destination of a file. It is called after the type has
+	// been detected, with Field, Filename and ContentType set. The returned
+	// writer is closed once the file has been written, also on failure.
+	// Files are written to temporary files when nil.
+	Sink func(file *UploadedFile) (io.WriteCloser, error)
+	// TempDir is the directory used for temporary files when Sink is nil
+	// (defaults to os.TempDir())
+	TempDir string
+}
+
+// ReadFiles reads a multipart/form-data request body, streaming each file
+// part to a sink instead of buffering it in memory like
+// http.Request.ParseMultipartForm does.
+//
+// The type of every file is detected from its first 512 bytes with
+// http.DetectContentType and checked against FileOptions.AllowedTypes before
+// the sink is opened. Size limits apply per file and to the whole body, and
+// at most FileOptions.MaxFiles files are accepted.
+//
+// Rejected uploads are returned as an *UploadError, which can be passed
+// straight to WriteErr. When ReadFiles fails, temporary files it created are
+// removed; data already written to a caller-provided Sink should be
+// discarded by the caller. Errors from the sink are returned unchanged.
+//
+// Parameters:
+//   - w: The http.ResponseWriter (used for MaxBytesReader)
+//   - r: The *http.Request containing the multipart body
+//   - opts: Limits, allowed types and the destination of files
+//
+// Example:
+//
+//	upload, err := netio.ReadFiles(w, r, netio.FileOptions{
+//	    MaxFileBytes: 5 << 20,
+//	    MaxFiles:     3,
+//	    AllowedTypes: []string{"image/png", "image/jpeg"},
+//	})
+//	if err != nil {
+//	    netio.WriteErr(w, err)
+//	    return
+//	}
+//	for _, f := range upload.Files {
+//	    defer os.Remove(f.Path)
+//	    ...
+//	}
+func ReadFiles(w http.ResponseWriter, r *http.Request, opts FileOptions) (*Upload, error) {
+	if opts.MaxFileBytes <= 0 {
+		opts.MaxFileBytes = DefaultMaxFileBytes
+	}
+	if opts.MaxTotalBytes <= 0 {
+		opts.MaxTotalBytes = DefaultMaxUploadBytes
+	}
+	if opts.MaxFiles <= 0 {
+		opts.MaxFiles = DefaultMaxFiles
+	}
+
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/form-data" || params["boundary"] == "" {
+		return nil, &UploadError{Kind: UploadContentType, Err: ErrUnsupportedContentType}
+	}
+
+	// set maximum bytes to receive to prevent/mitigate DOS on API
+	r.Body = http.MaxBytesReader(w, r.Body, opts.MaxTotalBytes)
+	mr := multipart.NewReader(r.Body, params["boundary"])
+
+	upload := &Upload{Values: make(url.Values)}
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			return upload, nil
+		}
+		if err != nil {
+			removeTempFiles(upload.Files)
+			return nil, uploadBodyError(err, opts.MaxTotalBytes)
+		}
+
+		field := part.FormName()
+		if part.FileName() == "" {
+			value, err := io.ReadAll(part)
+			if err != nil {
+				removeTempFiles(upload.Files)
+				return nil, uploadBodyError(err, opts.MaxTotalBytes)
+			}
+			upload.Values.Add(field, string(value))
+			continue
+		}
+
+		if len(upload.Files) == opts.MaxFiles {
+			removeTempFiles(upload.Files)
+			return nil, &UploadError{Kind: UploadTooManyFiles, Field: field, Limit: int64(opts.MaxFiles)}
+		}
+
+		file, err := readFile(part, opts)
+		if file != nil {
+			upload.Files = append(upload.Files, *file)
+		}
+		if err != nil {
+			removeTempFiles(upload.Files)
+			return nil, err
+		}
+	}
+}
+
+// readFile streams a single file part to its sink. The returned file is
+// not nil once the sink has been opened, so its temporary file can be
+// removed on failure.
+func readFile(part *multipart.Part, opts FileOptions) (*UploadedFile, error) {
+	file := &UploadedFile{
+		Field:    part.FormName(),
+		Filename: part.FileName(),
+	}
+
+	sniff := make([]byte, sniffLen)
+	n, err := io.ReadFull(part, sniff)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, uploadBodyError(err, opts.MaxTotalBytes)
+	}
+	sniff = sniff[:n]
+
+	file.ContentType = http.DetectContentType(sniff)
+	if !typeAllowed(file.ContentType, opts.AllowedTypes) {
+		return nil, &UploadError{
+			Kind:        UploadTypeNotAllowed,
+			Field:       file.Field,
+			Filename:    file.Filename,
+			ContentType: file.ContentType,
+		}
+	}
+
+	sink := opts.Sink
+	if sink == nil {
+		sink = tempFileSink(opts.TempDir)
+	}
+	dst, err := sink(file)
+	if err != nil {
+		return nil, err
+	}
+
+	// copy one byte past the limit to detect files that are too large
+	src := &readErrRecorder{r: io.MultiReader(bytes.NewReader(sniff), part)}
+	written, copyErr := io.Copy(dst, io.LimitReader(src, opts.MaxFileBytes+1))
+	closeErr := dst.Close()
+	file.Size = written
+
+	switch {
+	case src.err != nil:
+		return file, uploadBodyError(src.err, opts.MaxTotalBytes)
+	case copyErr != nil:
+		return file, copyErr
+	case written > opts.MaxFileBytes:
+		return file, &UploadError{
+			Kind:     UploadFileTooLarge,
+			Field:    file.Field,
+			Filename: file.Filename,
+			Limit:    opts.MaxFileBytes,
+		}
+	case closeErr != nil:
+		return file, closeErr
+	}
+	return file, nil
+}
+
+// readErrRecorder records the error returned by r, so failures reading the
+// request can be told apart from failures writing to the sink.
+type readErrRecorder struct {
+	r   io.Reader
+	err error
+}
+
+func (rr *readErrRecorder) Read(p []byte) (int, error) {
+	n, err := rr.r.Read(p)
+	if err != nil && !errors.Is(err, io.EOF) {
+		rr.err = err
+	}
+	return n, err
+}
+
+// tempFileSink returns a sink writing each file to a new temporary file in
+// dir and recording its path.
+func tempFileSink(dir string) func(file *UploadedFile) (io.WriteCloser, error) {
+	return func(file *UploadedFile) (io.WriteCloser, error) {
+		f, err := os.CreateTemp(dir, "netio-upload-*")
+		if err != nil {
+			return nil, err
+		}
+		file.Path = f.Name()
+		return f, nil
+	}
+}
+
+// removeTempFiles removes the temporary files created for files.
+func removeTempFiles(files []UploadedFile) {
+	for _, f := range files {
+		if f.Path != "" {
+			os.Remove(f.Path)
+		}
+	}
+}
+
+// typeAllowed reports whether the sniffed contentType matches one of the
+// allowed media types. "type/*" matches every subtype.
+func typeAllowed(contentType string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, a := range allowed {
+		a = strings.ToLower(a)
+		if a == mediaType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(a, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// uploadBodyError converts an error reading the multipart body into an
+// *UploadError.
+func uploadBodyError(err error, limit int64) error {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return &UploadError{Kind: UploadTooLarge, Limit: limit, Err: err}
+	}
+	return &UploadError{Kind: UploadMalformed, Err: err}
+}
@@ -0,0 +1,188 @@
+package netio
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// pngHeader is enough of a PNG file for http.DetectContentType.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n")
+
+type uploadPart struct {
+	field, filename string
+	content         []byte
+}
+
+// newUploadRequest returns a multipart/form-data request holding parts.
+// Parts without a filename are plain form fields.
+func newUploadRequest(parts ...uploadPart) *http.Request {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, p := range parts {
+		var w io.Writer
+		if p.filename == "" {
+			w, _ = mw.CreateFormField(p.field)
+		} else {
+			w, _ = mw.CreateFormFile(p.field, p.filename)
+		}
+		w.Write(p.content)
+	}
+	mw.Close()
+
+	r := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	return r
+}
+
+func TestReadFiles(t *testing.T) {
+	png := append(pngHeader, bytes.Repeat([]byte{0}, 100)...)
+	text := []byte("hello world")
+
+	t.Run("temp files", func(t *testing.T) {
+		dir := t.TempDir()
+		r := newUploadRequest(
+			uploadPart{"title", "", []byte("holiday")},
+			uploadPart{"photo", "../../etc/beach.png", png},
+			uploadPart{"notes", "notes.txt", text},
+		)
+
+		upload, err := ReadFiles(httptest.NewRecorder(), r, FileOptions{TempDir: dir})
+		if err != nil {
+			t.Fatalf("ReadFiles() error = %v", err)
+		}
+		if got := upload.Values.Get("title"); got != "holiday" {
+			t.Errorf("ReadFiles() title = %q, want holiday", got)
+		}
+		if len(upload.Files) != 2 {
+			t.Fatalf("ReadFiles() files = %d, want 2", len(upload.Files))
+		}
+
+		photo := upload.Files[0]
+		if photo.Field != "photo" || photo.Filename != "beach.png" || photo.ContentType != "image/png" || photo.Size != int64(len(png)) {
+			t.Errorf("ReadFiles() photo = %+v", photo)
+		}
+		if got, _ := os.ReadFile(photo.Path); !bytes.Equal(got, png) {
+			t.Error("ReadFiles() temp file does not hold the upload")
+		}
+		if got := upload.Files[1].ContentType; got != "text/plain; charset=utf-8" {
+			t.Errorf("ReadFiles() notes type = %q, want sniffed text/plain", got)
+		}
+	})
+
+	t.Run("sink", func(t *testing.T) {
+		var buf bytes.Buffer
+		sink := func(f *UploadedFile) (io.WriteCloser, error) {
+			return nopWriteCloser{&buf}, nil
+		}
+		r := newUploadRequest(uploadPart{"notes", "notes.txt", text})
+
+		upload, err := ReadFiles(httptest.NewRecorder(), r, FileOptions{Sink: sink})
+		if err != nil {
+			t.Fatalf("ReadFiles() error = %v", err)
+		}
+		if buf.String() != string(text) || upload.Files[0].Path != "" {
+			t.Errorf("ReadFiles() sink = %q, file = %+v", buf.String(), upload.Files[0])
+		}
+	})
+
+	tests := []struct {
+		name       string
+		req        *http.Request
+		opts       FileOptions
+		wantKind   UploadErrorKind
+		wantStatus int
+		wantKey    string
+	}{
+		{
+			name:       "type not allowed",
+			req:        newUploadRequest(uploadPart{"photo", "photo.png", png}, uploadPart{"avatar", "evil.png", text}),
+			opts:       FileOptions{AllowedTypes: []string{"image/*"}},
+			wantKind:   UploadTypeNotAllowed,
+			wantStatus: http.StatusUnsupportedMediaType,
+			wantKey:    "avatar",
+		},
+		{
+			name:       "file too large",
+			req:        newUploadRequest(uploadPart{"photo", "photo.png", png}),
+			opts:       FileOptions{MaxFileBytes: 50},
+			wantKind:   UploadFileTooLarge,
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantKey:    "photo",
+		},
+		{
+			name:       "too many files",
+			req:        newUploadRequest(uploadPart{"a", "a.txt", text}, uploadPart{"b", "b.txt", text}),
+			opts:       FileOptions{MaxFiles: 1},
+			wantKind:   UploadTooManyFiles,
+			wantStatus: http.StatusBadRequest,
+			wantKey:    "b",
+		},
+		{
+			name:       "body too large",
+			req:        newUploadRequest(uploadPart{"a", "a.txt", text}, uploadPart{"photo", "photo.png", png}),
+			opts:       FileOptions{MaxTotalBytes: 300},
+			wantKind:   UploadTooLarge,
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantKey:    "body",
+		},
+		{
+			name: "not multipart",
+			req: func() *http.Request {
+				r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`))
+				r.Header.Set("Content-Type", "application/json")
+				return r
+			}(),
+			wantKind:   UploadContentType,
+			wantStatus: http.StatusUnsupportedMediaType,
+			wantKey:    "body",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tc.opts.TempDir = dir
+
+			upload, err := ReadFiles(httptest.NewRecorder(), tc.req, tc.opts)
+			var uerr *UploadError
+			if !errors.As(err, &uerr) || uerr.Kind != tc.wantKind {
+				t.Fatalf("ReadFiles() error = %v, want kind %v", err, tc.wantKind)
+			}
+			if upload != nil {
+				t.Error("ReadFiles() returned an upload with an error")
+			}
+			if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+				t.Errorf("ReadFiles() left %d temporary files behind", len(entries))
+			}
+
+			w := httptest.NewRecorder()
+			WriteErr(w, err)
+			if w.Code != tc.wantStatus {
+				t.Errorf("WriteErr() code = %v, want %v", w.Code, tc.wantStatus)
+			}
+			var got struct {
+				Error struct {
+					Validation map[string]string `json:"validation"`
+				} `json:"error"`
+			}
+			json.NewDecoder(w.Body).Decode(&got)
+			if got.Error.Validation[tc.wantKey] != uerr.Message() {
+				t.Errorf("WriteErr() validation = %v, want %q: %q", got.Error.Validation, tc.wantKey, uerr.Message())
+			}
+		})
+	}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
@@ -1,15 +1,137 @@
 package netio
 
 import (
+	"net/http"
 	"regexp"
 	"slices"
+	"strconv"
+	"strings"
 )
 
 // Validator provides a structure for collecting and managing validation errors.
 // It maintains a map of field-specific error messages that can be accumulated
 // during the validation process.
+//
+// Errors holds the first message recorded for each field, while FieldErrors
+// holds every message in the order they were added. Which of the two is sent
+// to clients by netio.Error is controlled by the WithAllErrors option.
+//
+// Validators created with Scope or Index share their error maps with the
+// Validator they were created from and prefix every key they record.
 type Validator struct {
-	Errors map[string]string
+	Errors      map[string]string
+	FieldErrors map[string][]string
+
+	allErrors   bool
+	jsonPointer bool
+	path        []pathSegment
+}
+
+// pathSegment is a single element of a scoped Validator's key prefix.
+// Index segments are rendered with brackets in dotted keys (items[3]).
+type pathSegment struct {
+	name  string
+	index bool
+}
+
+// Validatable is implemented by types that can validate themselves.
+// ReadAndValidate and Handle call Validate automatically after decoding a
+// request body, so the check cannot be forgotten.
+//
+// Example:
+//
+//	type signupInput struct {
+//	    Email string `json:"email"`
+//	    Age   int    `json:"age"`
+//	}
+//
+//	func (in signupInput) Validate(v *netio.Validator) {
+//	    v.Check(netio.Matches(in.Email, netio.EmailRX), "email", "invalid email format")
+//	    v.Check(in.Age >= 18, "age", "must be over 18")
+//	}
+type Validatable interface {
+	Validate(v *Validator)
+}
+
+// ValidationError is returned when a decoded value fails its own validation.
+// It carries the Validator so the errors can be passed straight to
+// netio.Error, and it implements HTTPError with 422 Unprocessable Entity.
+type ValidationError struct {
+	Validator *Validator
+}
+
+// Error implements the error interface. The message lists the failing
+// keys but not the messages themselves.
+func (e *ValidationError) Error() string {
+	keys := make([]string, 0, len(e.Validator.Errors))
+	for key := range e.Validator.Errors {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return "netio: validation failed for " + strings.Join(keys, ", ")
+}
+
+// StatusCode returns 422 Unprocessable Entity.
+func (e *ValidationError) StatusCode() int {
+	return http.StatusUnprocessableEntity
+}
+
+// validator returns the Validator holding the failures.
+func (e *ValidationError) validator() *Validator {
+	return e.Validator
+}
+
+// validate runs dst's Validate method, if it has one, with a fresh
+// Validator and returns a *ValidationError when validation fails.
+func validate(dst any) error {
+	vd, ok := dst.(Validatable)
+	if !ok {
+		return nil
+	}
+
+	v := NewValidator()
+	vd.Validate(v)
+	if !v.Valid() {
+		return &ValidationError{Validator: v}
+	}
+	return nil
+}
+
+// ValidatorOption configures a Validator created by NewValidator.
+type ValidatorOption func(*Validator)
+
+// WithAllErrors makes the Validator report every message recorded for a
+// field instead of only the first one. Error responses then serialise each
+// field as an array of messages, and Validator.Struct checks every rule of a
+// field rather than stopping at the first failure.
+//
+// Example:
+//
+//	v := netio.NewValidator(netio.WithAllErrors())
+//	v.Check(len(password) >= 8, "password", "must be at least 8 characters")
+//	v.Check(hasDigit(password), "password", "must contain a digit")
+//
+//	// {"password": ["must be at least 8 characters", "must contain a digit"]}
+//	netio.Error(w, "error", http.StatusUnprocessableEntity, v)
+func WithAllErrors() ValidatorOption {
+	return func(v *Validator) {
+		v.allErrors = true
+	}
+}
+
+// WithJSONPointer makes the Validator render keys as RFC 6901 JSON Pointers
+// (e.g. "/items/3/price") instead of dotted paths (e.g. "items[3].price").
+// This lets front-ends map errors back onto form fields.
+//
+// Example:
+//
+//	v := netio.NewValidator(netio.WithJSONPointer())
+//	v.Check(input.Email != "", "email", "must be provided")    // key "/email"
+//	v.Scope("address").Check(false, "postcode", "is invalid") // key "/address/postcode"
+func WithJSONPointer() ValidatorOption {
+	return func(v *Validator) {
+		v.jsonPointer = true
+	}
 }
 
 // NewValidator is a helper function that creates and initializes a new
@@ -20,10 +142,15 @@ type Validator struct {
 //
 //	v := netio.NewValidator()
 //	v.Check(user.Age >= 18, "age", "must be at least 18 years old")
-func NewValidator() *Validator {
-	return &Validator{
-		Errors: make(map[string]string),
+func NewValidator(opts ...ValidatorOption) *Validator {
+	v := &Validator{
+		Errors:      make(map[string]string),
+		FieldErrors: make(map[string][]string),
 	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
 }
 
 // Valid returns true if the validator has no errors, false otherwise.
@@ -43,10 +170,130 @@ func (v *Validator) Valid() bool {
 
 // AddError adds an error message for a specific field to the validator's error map.
 // If an error already exists for the given key, it will not be overwritten.
+// Every message, including later ones for the same key, is also appended to
+// FieldErrors.
+//
+// On a scoped Validator the key is prefixed with the scope's path, and with
+// WithJSONPointer it is rendered as a JSON Pointer.
 func (v *Validator) AddError(key, message string) {
+	key = v.key(key)
+
 	if _, exist := v.Errors[key]; !exist {
 		v.Errors[key] = message
 	}
+	if v.FieldErrors == nil {
+		v.FieldErrors = make(map[string][]string)
+	}
+	v.FieldErrors[key] = append(v.FieldErrors[key], message)
+}
+
+// Scope returns a Validator that records errors under name, relative to
+// v's own scope. The returned Validator shares its errors with v, so
+// checking v.Valid() afterwards covers errors added through the scope.
+//
+// Example:
+//
+//	v := netio.NewValidator()
+//
+//	addr := v.Scope("address")
+//	addr.Check(input.Address.Postcode != "", "postcode", "must be provided")
+//
+//	// v.Errors: {"address.postcode": "must be provided"}
+func (v *Validator) Scope(name string) *Validator {
+	return v.child(pathSegment{name: name})
+}
+
+// Index returns a Validator that records errors under the i'th element of
+// the list called name, relative to v's own scope. If name is empty the
+// index is applied to v's scope directly.
+//
+// Example:
+//
+//	v := netio.NewValidator()
+//	for i, item := range input.Items {
+//	    iv := v.Index("items", i)
+//	    iv.Check(item.Price > 0, "price", "must be greater than zero")
+//	}
+//
+//	// v.Errors: {"items[3].price": "must be greater than zero"}
+//	// with WithJSONPointer: {"/items/3/price": "must be greater than zero"}
+func (v *Validator) Index(name string, i int) *Validator {
+	child := v
+	if name != "" {
+		child = v.Scope(name)
+	}
+	return child.child(pathSegment{name: strconv.Itoa(i), index: true})
+}
+
+// child returns a Validator sharing v's errors and options with segs
+// appended to its path.
+func (v *Validator) child(segs ...pathSegment) *Validator {
+	// maps must exist before they are shared, otherwise
+	// errors added to the child would never reach v
+	if v.Errors == nil {
+		v.Errors = make(map[string]string)
+	}
+	if v.FieldErrors == nil {
+		v.FieldErrors = make(map[string][]string)
+	}
+
+	path := make([]pathSegment, len(v.path), len(v.path)+len(segs))
+	copy(path, v.path)
+
+	return &Validator{
+		Errors:      v.Errors,
+		FieldErrors: v.FieldErrors,
+		allErrors:   v.allErrors,
+		jsonPointer: v.jsonPointer,
+		path:        append(path, segs...),
+	}
+}
+
+// key renders name, prefixed with v's scope, as an error key.
+func (v *Validator) key(name string) string {
+	if len(v.path) == 0 && !v.jsonPointer {
+		return name
+	}
+
+	segments := v.path
+	if name != "" {
+		segments = append(segments[:len(segments):len(segments)], pathSegment{name: name})
+	}
+
+	var b strings.Builder
+	for i, seg := range segments {
+		switch {
+		case v.jsonPointer:
+			b.WriteByte('/')
+			b.WriteString(escapePointer(seg.name))
+		case seg.index:
+			b.WriteByte('[')
+			b.WriteString(seg.name)
+			b.WriteByte(']')
+		default:
+			if i > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(seg.name)
+		}
+	}
+	return b.String()
+}
+
+// escapePointer escapes a JSON Pointer reference token (RFC 6901).
+func escapePointer(token string) string {
+	token = strings.ReplaceAll(token, "~", "~0")
+	return strings.ReplaceAll(token, "/", "~1")
+}
+
+// messages returns the validation errors in the shape sent to clients:
+// a map of strings by default, or a map of string slices when the
+// Validator was created with WithAllErrors.
+func (v *Validator) messages() any {
+	if v.allErrors {
+		return v.FieldErrors
+	}
+	return v.Errors
 }
 
 // Check performs a validation check based on a condition. If the condition is false,
@@ -0,0 +1,345 @@
+package netio
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// EmailRX is the regular expression used by the "email" validation rule.
+var EmailRX = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
+
+// Struct validates a struct using the rules declared in its `validate`
+// field tags and records any failures on the Validator.
+//
+// Errors are keyed by the field's `json` tag name (falling back to the Go
+// field name), relative to v's scope. Nested structs, slices and maps are
+// walked automatically and produce keys such as "address.postcode",
+// "items[3].price" and "labels[env]" (or JSON Pointers such as
+// "/items/3/price" with WithJSONPointer).
+//
+// Supported rules, separated by commas:
+//   - required: the value must not be empty (zero value, nil, or zero length)
+//   - min=N: minimum length for strings (in characters), slices and maps,
+//     or minimum value for numbers
+//   - max=N: maximum length for strings, slices and maps, or maximum value for numbers
+//   - email: the string must match EmailRX (uses netio.Matches)
+//   - oneof=a b c: the value must be one of the space separated options (uses netio.IsIn)
+//   - unique: the slice must not contain duplicate values (uses netio.HasDuplicates)
+//
+// Rules other than required are skipped for empty strings and nil pointers,
+// so optional fields are only checked when present. Only the first failing
+// rule of each field is recorded unless the Validator was created with
+// WithAllErrors.
+//
+// Struct panics if s is not a struct or a pointer to a struct, or if a tag
+// contains an unknown rule.
+//
+// Example:
+//
+//	var input struct {
+//	    Name    string   `json:"name" validate:"required,min=3,max=50"`
+//	    Email   string   `json:"email" validate:"required,email"`
+//	    Role    string   `json:"role" validate:"oneof=admin user"`
+//	    Tags    []string `json:"tags" validate:"max=5,unique"`
+//	    Address struct {
+//	        Postcode string `json:"postcode" validate:"required"`
+//	    } `json:"address"`
+//	}
+//
+//	v := netio.NewValidator()
+//	v.Struct(&input)
+//	if !v.Valid() {
+//	    netio.Error(w, "error", http.StatusUnprocessableEntity, v)
+//	    return
+//	}
+func (v *Validator) Struct(s any) {
+	rv := reflect.ValueOf(s)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("netio: Validator.Struct requires a struct, got %T", s))
+	}
+
+	w := structWalker{v: v}
+	w.walkStruct(rv)
+}
+
+// structWalker walks a value for Validator.Struct. path is the key of the
+// current value relative to v's scope; it is only turned into a scoped
+// Validator when a rule fails, so walking allocates nothing per element.
+type structWalker struct {
+	v    *Validator
+	path []pathSegment
+}
+
+// walkStruct validates each exported field of rv and descends into its value.
+func (w *structWalker) walkStruct(rv reflect.Value) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, ok := jsonFieldName(field)
+		if !ok {
+			continue
+		}
+
+		value := rv.Field(i)
+
+		// embedded structs without a json name are flattened by
+		// encoding/json, so their fields share the parent scope
+		if field.Anonymous && field.Tag.Get("json") == "" {
+			for value.Kind() == reflect.Pointer && !value.IsNil() {
+				value = value.Elem()
+			}
+			if value.Kind() == reflect.Struct {
+				w.walkStruct(value)
+				continue
+			}
+		}
+
+		if tag := field.Tag.Get("validate"); tag != "" {
+			if msgs := ruleErrors(value, tag, w.v.allErrors); len(msgs) > 0 {
+				scope := w.v.child(w.path...)
+				for _, msg := range msgs {
+					scope.AddError(name, msg)
+				}
+			}
+		}
+		if hasRules(field.Type) {
+			w.push(pathSegment{name: name})
+			w.walkValue(value)
+			w.pop()
+		}
+	}
+}
+
+// walkValue descends into structs, slices, arrays and maps so their
+// elements are validated under the current path.
+func (w *structWalker) walkValue(rv reflect.Value) {
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return
+		}
+		rv = rv.Elem()
+	}
+	if !hasRules(rv.Type()) {
+		return
+	}
+
+	switch rv.Kind() {
+	case reflect.Struct:
+		w.walkStruct(rv)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			w.push(pathSegment{name: strconv.Itoa(i), index: true})
+			w.walkValue(rv.Index(i))
+			w.pop()
+		}
+	case reflect.Map:
+		iter := rv.MapRange()
+		for iter.Next() {
+			w.push(pathSegment{name: fmt.Sprint(iter.Key().Interface()), index: true})
+			w.walkValue(iter.Value())
+			w.pop()
+		}
+	}
+}
+
+func (w *structWalker) push(seg pathSegment) { w.path = append(w.path, seg) }
+func (w *structWalker) pop()                 { w.path = w.path[:len(w.path)-1] }
+
+// rulesCache maps a reflect.Type to whether values of that type can hold
+// fields with `validate` tags, see hasRules.
+var rulesCache sync.Map
+
+// hasRules reports whether values of type t can contain a struct field with
+// a `validate` tag, in which case Validator.Struct has to walk them. Scalars
+// and lists of scalars never do, so e.g. a large []byte is not walked.
+// Interfaces may hold anything and always report true.
+func hasRules(t reflect.Type) bool {
+	if cached, ok := rulesCache.Load(t); ok {
+		return cached.(bool)
+	}
+	return computeHasRules(t, map[reflect.Type]bool{})
+}
+
+// computeHasRules implements hasRules. visiting holds the struct types
+// being inspected, so recursive types terminate.
+func computeHasRules(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	if cached, ok := rulesCache.Load(t); ok {
+		return cached.(bool)
+	}
+
+	var result bool
+	switch t.Kind() {
+	case reflect.Interface:
+		result = true
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		result = computeHasRules(t.Elem(), visiting)
+	case reflect.Struct:
+		if visiting[t] {
+			// the answer depends on the other fields of t
+			return false
+		}
+		visiting[t] = true
+		for i := 0; i < t.NumField() && !result; i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if _, ok := jsonFieldName(field); !ok {
+				continue
+			}
+			result = field.Tag.Get("validate") != "" || computeHasRules(field.Type, visiting)
+		}
+		delete(visiting, t)
+	}
+
+	if result || len(visiting) == 0 {
+		// false results computed inside a cycle may be incomplete
+		rulesCache.Store(t, result)
+	}
+	return result
+}
+
+// ruleErrors applies the comma separated rules in tag to rv and returns the
+// messages of the failing rules. It stops at the first failure unless all
+// is set.
+func ruleErrors(rv reflect.Value, tag string, all bool) []string {
+	var msgs []string
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+
+		if name == "required" {
+			if isEmptyValue(rv) {
+				return append(msgs, "must be provided")
+			}
+			continue
+		}
+
+		value := rv
+		for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+			if value.IsNil() {
+				return msgs
+			}
+			value = value.Elem()
+		}
+		if value.Kind() == reflect.String && value.Len() == 0 {
+			return msgs
+		}
+
+		if msg, ok := applyRule(name, param, value); !ok {
+			msgs = append(msgs, msg)
+			if !all {
+				return msgs
+			}
+		}
+	}
+	return msgs
+}
+
+// applyRule runs a single named rule against value. It returns the error
+// message and false when the rule fails.
+func applyRule(name, param string, value reflect.Value) (string, bool) {
+	switch name {
+	case "min", "max":
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			panic(fmt.Sprintf("netio: invalid %s parameter %q", name, param))
+		}
+		return checkBound(name, param, limit, value)
+
+	case "email":
+		if value.Kind() != reflect.String {
+			panic("netio: email rule requires a string field")
+		}
+		return "must be a valid email address", Matches(value.String(), EmailRX)
+
+	case "oneof":
+		options := strings.Fields(param)
+		return "must be one of: " + strings.Join(options, ", "), IsIn(fmt.Sprint(value.Interface()), options...)
+
+	case "unique":
+		if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+			panic("netio: unique rule requires a slice or array field")
+		}
+		if !value.Type().Elem().Comparable() {
+			panic("netio: unique rule requires comparable elements")
+		}
+		values := make([]any, value.Len())
+		for i := range values {
+			values[i] = value.Index(i).Interface()
+		}
+		return "must not contain duplicate values", !HasDuplicates(values)
+
+	default:
+		panic(fmt.Sprintf("netio: unknown validation rule %q", name))
+	}
+}
+
+// checkBound implements the min and max rules.
+func checkBound(name, param string, limit float64, value reflect.Value) (string, bool) {
+	var n float64
+	var unit string
+
+	switch value.Kind() {
+	case reflect.String:
+		n, unit = float64(utf8.RuneCountInString(value.String())), " characters long"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		n, unit = float64(value.Len()), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n = float64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		n = value.Float()
+	default:
+		panic(fmt.Sprintf("netio: %s rule is not supported for %s fields", name, value.Kind()))
+	}
+
+	if name == "min" {
+		if unit == " items" {
+			return "must contain at least " + param + unit, n >= limit
+		}
+		return "must be at least " + param + unit, n >= limit
+	}
+	if unit == " items" {
+		return "must not contain more than " + param + unit, n <= limit
+	}
+	return "must not be more than " + param + unit, n <= limit
+}
+
+// isEmptyValue reports whether rv fails the required rule.
+func isEmptyValue(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return rv.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return rv.IsNil()
+	default:
+		return rv.IsZero()
+	}
+}
+
+// jsonFieldName returns the name encoding/json would use for field. It
+// returns false for fields that are skipped with `json:"-"`.
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name, true
+}
@@ -0,0 +1,207 @@
+package netio
+
+import (
+	"testing"
+)
+
+type testAddress struct {
+	Postcode string `json:"postcode" validate:"required,min=4,max=4"`
+}
+
+type testItem struct {
+	Name  string  `json:"name" validate:"required"`
+	Price float64 `json:"price" validate:"min=0.01"`
+}
+
+type testInput struct {
+	Name     string              `json:"name" validate:"required,min=3,max=10"`
+	Email    string              `json:"email" validate:"email"`
+	Role     string              `json:"role" validate:"oneof=admin user"`
+	Age      int                 `json:"age" validate:"min=18"`
+	Tags     []string            `json:"tags" validate:"max=3,unique"`
+	Nickname *string             `json:"nickname" validate:"min=2"`
+	Address  testAddress         `json:"address"`
+	Items    []testItem          `json:"items" validate:"required"`
+	Meta     map[string]testItem `json:"meta"`
+	Ignored  string              `json:"-" validate:"required"`
+}
+
+func TestValidator_Struct(t *testing.T) {
+	short := "x"
+
+	tests := []struct {
+		name       string
+		input      testInput
+		wantErrors map[string]string
+	}{
+		{
+			name: "valid input",
+			input: testInput{
+				Name:    "jack",
+				Email:   "jack@example.com",
+				Role:    "admin",
+				Age:     30,
+				Tags:    []string{"a", "b"},
+				Address: testAddress{Postcode: "3000"},
+				Items:   []testItem{{Name: "pen", Price: 1.5}},
+			},
+			wantErrors: map[string]string{},
+		},
+		{
+			name: "invalid input",
+			input: testInput{
+				Name:     "jo",
+				Email:    "not-an-email",
+				Role:     "superuser",
+				Age:      15,
+				Tags:     []string{"a", "a"},
+				Nickname: &short,
+				Address:  testAddress{Postcode: ""},
+				Items:    []testItem{{Name: "pen", Price: 1}, {Name: "", Price: 0}},
+				Meta:     map[string]testItem{"extra": {Name: "x", Price: -1}},
+			},
+			wantErrors: map[string]string{
+				"name":              "must be at least 3 characters long",
+				"email":             "must be a valid email address",
+				"role":              "must be one of: admin, user",
+				"age":               "must be at least 18",
+				"tags":              "must not contain duplicate values",
+				"nickname":          "must be at least 2 characters long",
+				"address.postcode":  "must be provided",
+				"items[1].name":     "must be provided",
+				"items[1].price":    "must be at least 0.01",
+				"meta[extra].price": "must be at least 0.01",
+			},
+		},
+		{
+			name: "missing required slice",
+			input: testInput{
+				Name:    "jack",
+				Age:     18,
+				Address: testAddress{Postcode: "3000"},
+			},
+			wantErrors: map[string]string{
+				"items": "must be provided",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			v := NewValidator()
+			v.Struct(&tc.input)
+
+			if len(v.Errors) != len(tc.wantErrors) {
+				t.Errorf("Struct() errors = %v, want %v", v.Errors, tc.wantErrors)
+			}
+			for key, msg := range tc.wantErrors {
+				if v.Errors[key] != msg {
+					t.Errorf("Struct() error[%q] = %q, want %q", key, v.Errors[key], msg)
+				}
+			}
+		})
+	}
+}
+
+func TestValidator_StructPanics(t *testing.T) {
+	tests := []struct {
+		name  string
+		input any
+	}{
+		{"not a struct", 42},
+		{"unknown rule", &struct {
+			Name string `validate:"bogus"`
+		}{Name: "x"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("Struct() did not panic")
+				}
+			}()
+			NewValidator().Struct(tc.input)
+		})
+	}
+}
+
+func TestValidator_StructAllErrors(t *testing.T) {
+	input := struct {
+		Password string `json:"password" validate:"min=8,oneof=secret"`
+	}{Password: "abc"}
+
+	v := NewValidator(WithAllErrors())
+	v.Struct(&input)
+
+	if len(v.FieldErrors["password"]) != 2 {
+		t.Errorf("Struct() FieldErrors = %v, want 2 messages", v.FieldErrors)
+	}
+}
+
+func TestValidator_StructJSONPointer(t *testing.T) {
+	input := struct {
+		Items []testItem `json:"items"`
+	}{Items: []testItem{{Name: "pen", Price: 1}, {Name: "cup", Price: 0}}}
+
+	v := NewValidator(WithJSONPointer())
+	v.Struct(&input)
+
+	if v.Errors["/items/1/price"] != "must be at least 0.01" {
+		t.Errorf("Struct() errors = %v, want /items/1/price", v.Errors)
+	}
+}
+
+func TestValidator_StructLargeScalarSlices(t *testing.T) {
+	input := struct {
+		Data  []byte           `json:"data"`
+		IDs   []int            `json:"ids" validate:"min=1"`
+		Index map[string]int64 `json:"index"`
+		Items []testItem       `json:"items"`
+	}{
+		Data:  make([]byte, 1<<20),
+		IDs:   make([]int, 1<<20),
+		Index: map[string]int64{"a": 1},
+		Items: []testItem{{Name: "pen", Price: 1}},
+	}
+
+	// lists of scalars cannot hold validate tags and are not walked
+	allocs := testing.AllocsPerRun(5, func() {
+		NewValidator().Struct(&input)
+	})
+	if allocs > 20 {
+		t.Errorf("Struct() allocations = %v, want scalar slices to be skipped", allocs)
+	}
+}
+
+// testNode is a recursive type with rules on its elements.
+type testNode struct {
+	Name     string     `json:"name" validate:"required"`
+	Children []testNode `json:"children"`
+	Extra    any        `json:"extra"`
+}
+
+func TestValidator_StructRecursive(t *testing.T) {
+	input := testNode{
+		Name:     "root",
+		Children: []testNode{{Name: "a"}, {Children: []testNode{{}}}},
+		Extra:    testItem{Price: 1},
+	}
+
+	v := NewValidator()
+	v.Struct(&input)
+
+	want := map[string]string{
+		"children[1].name":             "must be provided",
+		"children[1].children[0].name": "must be provided",
+		"extra.name":                   "must be provided",
+	}
+	if len(v.Errors) != len(want) {
+		t.Errorf("Struct() errors = %v, want %v", v.Errors, want)
+	}
+	for key, msg := range want {
+		if v.Errors[key] != msg {
+			t.Errorf("Struct() errors[%q] = %q, want %q", key, v.Errors[key], msg)
+		}
+	}
+}
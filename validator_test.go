@@ -151,3 +151,73 @@ func TestMatches(t *testing.T) {
 		})
 	}
 }
+
+func TestValidator_AllErrors(t *testing.T) {
+	// default mode keeps the first error but still records every message
+	v := NewValidator()
+	v.AddError("password", "too short")
+	v.AddError("password", "missing digit")
+
+	if v.Errors["password"] != "too short" {
+		t.Errorf("AddError() Errors = %v, want first message", v.Errors)
+	}
+	if len(v.FieldErrors["password"]) != 2 {
+		t.Errorf("AddError() FieldErrors = %v, want 2 messages", v.FieldErrors)
+	}
+	if _, ok := BuildErrorWithValidation(400, v).ValidationErrors.(map[string]string); !ok {
+		t.Error("BuildErrorWithValidation() default mode did not serialise single messages")
+	}
+
+	// all errors mode serialises arrays
+	v = NewValidator(WithAllErrors())
+	v.Check(false, "password", "too short")
+	v.Check(false, "password", "missing digit")
+
+	got, ok := BuildErrorWithValidation(400, v).ValidationErrors.(map[string][]string)
+	if !ok {
+		t.Fatal("BuildErrorWithValidation() all errors mode did not serialise arrays")
+	}
+	if len(got["password"]) != 2 {
+		t.Errorf("BuildErrorWithValidation() password = %v, want 2 messages", got["password"])
+	}
+}
+
+func TestValidator_Scope(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []ValidatorOption
+		want []string
+	}{
+		{"dotted keys", nil, []string{"email", "address.postcode", "items[3].price", "items[3].tags[0]"}},
+		{"json pointer keys", []ValidatorOption{WithJSONPointer()}, []string{"/email", "/address/postcode", "/items/3/price", "/items/3/tags/0"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			v := NewValidator(tc.opts...)
+			v.AddError("email", "error")
+			v.Scope("address").AddError("postcode", "error")
+			item := v.Index("items", 3)
+			item.AddError("price", "error")
+			item.Index("tags", 0).AddError("", "error")
+
+			for _, key := range tc.want {
+				if _, ok := v.Errors[key]; !ok {
+					t.Errorf("Scope() missing key %q in %v", key, v.Errors)
+				}
+			}
+			if len(v.Errors) != len(tc.want) {
+				t.Errorf("Scope() errors = %v, want keys %v", v.Errors, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidator_JSONPointerEscaping(t *testing.T) {
+	v := NewValidator(WithJSONPointer())
+	v.Scope("a/b").AddError("c~d", "error")
+
+	if _, ok := v.Errors["/a~1b/c~0d"]; !ok {
+		t.Errorf("AddError() errors = %v, want key /a~1b/c~0d", v.Errors)
+	}
+}